@@ -9,6 +9,7 @@ import (
 	"github.com/spf13/afero"
 	"github.com/suzuki-shunsuke/slog-util/slogutil"
 	"github.com/suzuki-shunsuke/tfrstate/pkg/controller/find"
+	"github.com/suzuki-shunsuke/tfrstate/pkg/tfrstate"
 	"github.com/urfave/cli/v3"
 )
 
@@ -19,19 +20,35 @@ type GlobalArgs struct {
 type FindArgs struct {
 	*GlobalArgs
 
-	OutputFormat string
-	PlanFile     string
-	BaseDir      string
-	BackendDir   string
-	S3Bucket     string
-	S3Key        string
-	GCSBucket    string
-	GCSPrefix    string
-	Outputs      []string
+	OutputFormat   string
+	OutputVersion  int
+	PlanFile       string
+	BaseDir        string
+	BackendDir     string
+	S3Bucket       string
+	S3Key          string
+	S3Endpoint     string
+	S3Region       string
+	GCSBucket      string
+	GCSPrefix      string
+	Outputs        []string
+	LinkTemplate   string
+	Repo           string
+	SHA            string
+	GroupByDir     bool
+	Summary        bool
+	Strict         bool
+	ExitCode       bool
+	Vars           []string
+	VarFiles       []string
+	BackendConfigs []string
+	InitState      string
 }
 
 type findCommand struct {
-	Stdout io.Writer
+	Stdout  io.Writer
+	Stderr  io.Writer
+	Version string
 }
 
 func (rc *findCommand) command(logger *slogutil.Logger, globalArgs *GlobalArgs) *cli.Command {
@@ -47,10 +64,16 @@ func (rc *findCommand) command(logger *slogutil.Logger, globalArgs *GlobalArgs)
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:        "output-format",
-				Usage:       "Output format. One of 'json' (default), 'markdown'",
+				Usage:       "Output format. One of 'json' (default), 'markdown', 'sarif', 'github-actions', 'junit', 'csv', 'jsonl'",
 				Value:       "json",
 				Destination: &args.OutputFormat,
 			},
+			&cli.IntFlag{
+				Name:        "output-version",
+				Usage:       "The version of the json output format. 1 (default) outputs an array of changes and 2 outputs an object including the tfrstate version, the backend, changed outputs, and diagnostics",
+				Value:       1,
+				Destination: &args.OutputVersion,
+			},
 			&cli.StringFlag{
 				Name:        "plan-json",
 				Usage:       "The file path to the plan file in JSON format",
@@ -76,6 +99,16 @@ func (rc *findCommand) command(logger *slogutil.Logger, globalArgs *GlobalArgs)
 				Usage:       "S3 Bucket Key of terraform_remote_state data source",
 				Destination: &args.S3Key,
 			},
+			&cli.StringFlag{
+				Name:        "s3-endpoint",
+				Usage:       "Custom S3 endpoint of terraform_remote_state data source such as MinIO",
+				Destination: &args.S3Endpoint,
+			},
+			&cli.StringFlag{
+				Name:        "s3-region",
+				Usage:       "AWS Region of the S3 Bucket of terraform_remote_state data source. This is used to identify the AWS partition",
+				Destination: &args.S3Region,
+			},
 			&cli.StringFlag{
 				Name:        "gcs-bucket",
 				Usage:       "GCS Bucket Name of terraform_remote_state data source",
@@ -92,6 +125,64 @@ func (rc *findCommand) command(logger *slogutil.Logger, globalArgs *GlobalArgs)
 				Aliases:     []string{"o"},
 				Destination: &args.Outputs,
 			},
+			&cli.StringFlag{
+				Name:        "link-template",
+				Usage:       "A template of links to files in the markdown output. {repo}, {sha}, {path}, and {line} are replaced. e.g. https://github.com/{repo}/blob/{sha}/{path}#L{line}",
+				Destination: &args.LinkTemplate,
+			},
+			&cli.StringFlag{
+				Name:        "repo",
+				Usage:       "A repository name replacing {repo} in --link-template",
+				Sources:     cli.EnvVars("GITHUB_REPOSITORY"),
+				Destination: &args.Repo,
+			},
+			&cli.StringFlag{
+				Name:        "sha",
+				Usage:       "A commit SHA replacing {sha} in --link-template",
+				Sources:     cli.EnvVars("GITHUB_SHA"),
+				Destination: &args.SHA,
+			},
+			&cli.BoolFlag{
+				Name:        "markdown-group-by-dir",
+				Usage:       "Group files by directory with <details> sections in the markdown output",
+				Destination: &args.GroupByDir,
+			},
+			&cli.BoolFlag{
+				Name:        "markdown-summary",
+				Usage:       "Output a summary header with counts and the backend configuration in the markdown output",
+				Destination: &args.Summary,
+			},
+			&cli.BoolFlag{
+				Name:        "strict",
+				Usage:       "Fail if some consumers such as terraform_remote_state data sources can't be resolved statically or some files have error diagnostics",
+				Destination: &args.Strict,
+			},
+			&cli.BoolFlag{
+				Name:        "exit-code",
+				Usage:       "Exit with 0 if no consumers are affected, 2 if some consumers are affected, and 1 on errors like terraform plan -detailed-exitcode",
+				Destination: &args.ExitCode,
+			},
+			&cli.StringSliceFlag{
+				Name:        "var",
+				Usage:       "A variable to resolve configurations of terraform_remote_state and backend. The format is <name>=<value>",
+				Destination: &args.Vars,
+			},
+			&cli.StringSliceFlag{
+				Name:        "var-file",
+				Usage:       "A variable definitions file to resolve configurations of terraform_remote_state and backend",
+				Destination: &args.VarFiles,
+			},
+			&cli.StringSliceFlag{
+				Name:        "backend-config",
+				Usage:       "A partial backend configuration like terraform init -backend-config. Either a file path or <key>=<value>. If this isn't set, a *.tfbackend file in --backend-dir is used if it's the only one",
+				Destination: &args.BackendConfigs,
+			},
+			&cli.StringFlag{
+				Name:        "init-state",
+				Usage:       "How to use .terraform/terraform.tfstate created by terraform init in --backend-dir. One of 'fallback' (default, used if the backend block isn't found or can't be resolved statically), 'prefer', and 'ignore'",
+				Value:       tfrstate.InitStateFallback,
+				Destination: &args.InitState,
+			},
 		},
 	}
 }
@@ -106,16 +197,34 @@ func (rc *findCommand) action(ctx context.Context, logger *slogutil.Logger, args
 		return fmt.Errorf("get the current directory: %w", err)
 	}
 	return find.Find(ctx, logger.Logger, fs, &find.Param{ //nolint:wrapcheck
-		Format:    args.OutputFormat,
-		PlanFile:  args.PlanFile,
-		Root:      args.BaseDir,
-		Dir:       args.BackendDir,
-		Key:       args.S3Key,
-		Bucket:    args.S3Bucket,
-		GCSPrefix: args.GCSPrefix,
-		GCSBucket: args.GCSBucket,
-		Outputs:   args.Outputs,
-		Stdout:    rc.Stdout,
-		PWD:       pwd,
+		Format:         args.OutputFormat,
+		OutputVersion:  args.OutputVersion,
+		Version:        rc.Version,
+		PlanFile:       args.PlanFile,
+		Root:           args.BaseDir,
+		Dir:            args.BackendDir,
+		Key:            args.S3Key,
+		Bucket:         args.S3Bucket,
+		S3Endpoint:     args.S3Endpoint,
+		S3Region:       args.S3Region,
+		GCSPrefix:      args.GCSPrefix,
+		GCSBucket:      args.GCSBucket,
+		Outputs:        args.Outputs,
+		Stdout:         rc.Stdout,
+		Stderr:         rc.Stderr,
+		PWD:            pwd,
+		Strict:         args.Strict,
+		ExitCode:       args.ExitCode,
+		Vars:           args.Vars,
+		VarFiles:       args.VarFiles,
+		BackendConfigs: args.BackendConfigs,
+		InitState:      args.InitState,
+		Markdown: &find.MarkdownOption{
+			LinkTemplate: args.LinkTemplate,
+			Repo:         args.Repo,
+			SHA:          args.SHA,
+			GroupByDir:   args.GroupByDir,
+			Summary:      args.Summary,
+		},
 	})
 }
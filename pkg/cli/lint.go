@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/slog-util/slogutil"
+	"github.com/suzuki-shunsuke/tfrstate/pkg/controller/lint"
+	"github.com/suzuki-shunsuke/tfrstate/pkg/tfrstate"
+	"github.com/urfave/cli/v3"
+)
+
+type LintArgs struct {
+	*GlobalArgs
+
+	OutputFormat string
+	BaseDir      string
+	Vars         []string
+	VarFiles     []string
+	InitState    string
+}
+
+type lintCommand struct {
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+func (rc *lintCommand) command(logger *slogutil.Logger, globalArgs *GlobalArgs) *cli.Command {
+	args := &LintArgs{
+		GlobalArgs: globalArgs,
+	}
+	return &cli.Command{
+		Name:  "lint",
+		Usage: "Validate the config of terraform_remote_state data sources against the backend of the producer",
+		Action: func(ctx context.Context, _ *cli.Command) error {
+			return rc.action(ctx, logger, args)
+		},
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "output-format",
+				Usage:       "Output format. One of 'text' (default, diagnostics are output to stderr) and 'json'",
+				Value:       "text",
+				Destination: &args.OutputFormat,
+			},
+			&cli.StringFlag{
+				Name:        "base-dir",
+				Usage:       "The file path to the directory where Terraform configuration files are located",
+				Destination: &args.BaseDir,
+			},
+			&cli.StringSliceFlag{
+				Name:        "var",
+				Usage:       "A variable to resolve configurations of terraform_remote_state and backend. The format is <name>=<value>",
+				Destination: &args.Vars,
+			},
+			&cli.StringSliceFlag{
+				Name:        "var-file",
+				Usage:       "A variable definitions file to resolve configurations of terraform_remote_state and backend",
+				Destination: &args.VarFiles,
+			},
+			&cli.StringFlag{
+				Name:        "init-state",
+				Usage:       "How to use .terraform/terraform.tfstate created by terraform init. One of 'fallback' (default), 'prefer', and 'ignore'",
+				Value:       tfrstate.InitStateFallback,
+				Destination: &args.InitState,
+			},
+		},
+	}
+}
+
+func (rc *lintCommand) action(ctx context.Context, logger *slogutil.Logger, args *LintArgs) error {
+	fs := afero.NewOsFs()
+	if err := logger.SetLevel(args.LogLevel); err != nil {
+		return fmt.Errorf("set log level: %w", err)
+	}
+	pwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get the current directory: %w", err)
+	}
+	return lint.Lint(ctx, logger.Logger, fs, &lint.Param{ //nolint:wrapcheck
+		Format:    args.OutputFormat,
+		Root:      args.BaseDir,
+		PWD:       pwd,
+		Stdout:    rc.Stdout,
+		Stderr:    rc.Stderr,
+		Vars:      args.Vars,
+		VarFiles:  args.VarFiles,
+		InitState: args.InitState,
+	})
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/slog-util/slogutil"
+	"github.com/suzuki-shunsuke/tfrstate/pkg/controller/rewrite"
+	"github.com/suzuki-shunsuke/tfrstate/pkg/tfrstate"
+	"github.com/urfave/cli/v3"
+)
+
+type RewriteArgs struct {
+	*GlobalArgs
+
+	From           string
+	To             string
+	DryRun         bool
+	BaseDir        string
+	BackendDir     string
+	Vars           []string
+	VarFiles       []string
+	BackendConfigs []string
+	InitState      string
+}
+
+type rewriteCommand struct {
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+func (rc *rewriteCommand) command(logger *slogutil.Logger, globalArgs *GlobalArgs) *cli.Command {
+	args := &RewriteArgs{
+		GlobalArgs: globalArgs,
+	}
+	return &cli.Command{
+		Name:  "rewrite",
+		Usage: "Rename an output in references of consumers of the given Terraform Root Module",
+		Action: func(ctx context.Context, _ *cli.Command) error {
+			return rc.action(ctx, logger, args)
+		},
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "from",
+				Usage:       "The old output name",
+				Required:    true,
+				Destination: &args.From,
+			},
+			&cli.StringFlag{
+				Name:        "to",
+				Usage:       "The new output name",
+				Required:    true,
+				Destination: &args.To,
+			},
+			&cli.BoolFlag{
+				Name:        "dry-run",
+				Usage:       "Output a unified diff instead of rewriting files",
+				Destination: &args.DryRun,
+			},
+			&cli.StringFlag{
+				Name:        "base-dir",
+				Usage:       "The file path to the directory where Terraform configuration files are located",
+				Destination: &args.BaseDir,
+			},
+			&cli.StringFlag{
+				Name:        "backend-dir",
+				Usage:       "The file path to the given Terraform Root Module",
+				Destination: &args.BackendDir,
+			},
+			&cli.StringSliceFlag{
+				Name:        "var",
+				Usage:       "A variable to resolve configurations of terraform_remote_state and backend. The format is <name>=<value>",
+				Destination: &args.Vars,
+			},
+			&cli.StringSliceFlag{
+				Name:        "var-file",
+				Usage:       "A variable definitions file to resolve configurations of terraform_remote_state and backend",
+				Destination: &args.VarFiles,
+			},
+			&cli.StringSliceFlag{
+				Name:        "backend-config",
+				Usage:       "A partial backend configuration like terraform init -backend-config. Either a file path or <key>=<value>",
+				Destination: &args.BackendConfigs,
+			},
+			&cli.StringFlag{
+				Name:        "init-state",
+				Usage:       "How to use .terraform/terraform.tfstate created by terraform init in --backend-dir. One of 'fallback' (default), 'prefer', and 'ignore'",
+				Value:       tfrstate.InitStateFallback,
+				Destination: &args.InitState,
+			},
+		},
+	}
+}
+
+func (rc *rewriteCommand) action(ctx context.Context, logger *slogutil.Logger, args *RewriteArgs) error {
+	fs := afero.NewOsFs()
+	if err := logger.SetLevel(args.LogLevel); err != nil {
+		return fmt.Errorf("set log level: %w", err)
+	}
+	pwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get the current directory: %w", err)
+	}
+	return rewrite.Rewrite(ctx, logger.Logger, fs, &rewrite.Param{ //nolint:wrapcheck
+		From:           args.From,
+		To:             args.To,
+		DryRun:         args.DryRun,
+		Dir:            args.BackendDir,
+		Root:           args.BaseDir,
+		PWD:            pwd,
+		Stdout:         rc.Stdout,
+		Stderr:         rc.Stderr,
+		Vars:           args.Vars,
+		VarFiles:       args.VarFiles,
+		BackendConfigs: args.BackendConfigs,
+		InitState:      args.InitState,
+	})
+}
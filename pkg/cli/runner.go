@@ -22,8 +22,25 @@ func Run(ctx context.Context, logger *slogutil.Logger, env *urfave.Env) error {
 		},
 		Commands: []*cli.Command{
 			(&findCommand{
+				Stdout:  env.Stdout,
+				Stderr:  env.Stderr,
+				Version: env.Version,
+			}).command(logger, globalArgs),
+			(&lintCommand{
+				Stdout: env.Stdout,
+				Stderr: env.Stderr,
+			}).command(logger, globalArgs),
+			(&unusedCommand{
 				Stdout: env.Stdout,
+				Stderr: env.Stderr,
 			}).command(logger, globalArgs),
+			(&rewriteCommand{
+				Stdout: env.Stdout,
+				Stderr: env.Stderr,
+			}).command(logger, globalArgs),
+			(&schemaCommand{
+				Stdout: env.Stdout,
+			}).command(),
 		},
 	}).Run(ctx, env.Args)
 }
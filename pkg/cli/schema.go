@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/suzuki-shunsuke/tfrstate/pkg/schema"
+	"github.com/urfave/cli/v3"
+)
+
+type schemaCommand struct {
+	Stdout io.Writer
+}
+
+func (rc *schemaCommand) command() *cli.Command {
+	return &cli.Command{
+		Name:  "schema",
+		Usage: "Output the JSON Schema of the json output format with --output-version 2",
+		Action: func(context.Context, *cli.Command) error {
+			return rc.action()
+		},
+	}
+}
+
+func (rc *schemaCommand) action() error {
+	if _, err := rc.Stdout.Write(schema.OutputV2); err != nil {
+		return fmt.Errorf("output the JSON Schema: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/slog-util/slogutil"
+	"github.com/suzuki-shunsuke/tfrstate/pkg/controller/unused"
+	"github.com/suzuki-shunsuke/tfrstate/pkg/tfrstate"
+	"github.com/urfave/cli/v3"
+)
+
+type UnusedArgs struct {
+	*GlobalArgs
+
+	OutputFormat string
+	BaseDir      string
+	Vars         []string
+	VarFiles     []string
+	InitState    string
+}
+
+type unusedCommand struct {
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+func (rc *unusedCommand) command(logger *slogutil.Logger, globalArgs *GlobalArgs) *cli.Command {
+	args := &UnusedArgs{
+		GlobalArgs: globalArgs,
+	}
+	return &cli.Command{
+		Name:  "unused",
+		Usage: "Find data sources which are never referenced and outputs of producers which no consumer refers",
+		Action: func(ctx context.Context, _ *cli.Command) error {
+			return rc.action(ctx, logger, args)
+		},
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "output-format",
+				Usage:       "Output format. One of 'text' (default) and 'json'",
+				Value:       "text",
+				Destination: &args.OutputFormat,
+			},
+			&cli.StringFlag{
+				Name:        "base-dir",
+				Usage:       "The file path to the directory where Terraform configuration files are located",
+				Destination: &args.BaseDir,
+			},
+			&cli.StringSliceFlag{
+				Name:        "var",
+				Usage:       "A variable to resolve configurations of consumers and backends. The format is <name>=<value>",
+				Destination: &args.Vars,
+			},
+			&cli.StringSliceFlag{
+				Name:        "var-file",
+				Usage:       "A variable definitions file to resolve configurations of consumers and backends",
+				Destination: &args.VarFiles,
+			},
+			&cli.StringFlag{
+				Name:        "init-state",
+				Usage:       "How to use .terraform/terraform.tfstate created by terraform init. One of 'fallback' (default), 'prefer', and 'ignore'",
+				Value:       tfrstate.InitStateFallback,
+				Destination: &args.InitState,
+			},
+		},
+	}
+}
+
+func (rc *unusedCommand) action(ctx context.Context, logger *slogutil.Logger, args *UnusedArgs) error {
+	fs := afero.NewOsFs()
+	if err := logger.SetLevel(args.LogLevel); err != nil {
+		return fmt.Errorf("set log level: %w", err)
+	}
+	pwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get the current directory: %w", err)
+	}
+	return unused.Unused(ctx, logger.Logger, fs, &unused.Param{ //nolint:wrapcheck
+		Format:    args.OutputFormat,
+		Root:      args.BaseDir,
+		PWD:       pwd,
+		Stdout:    rc.Stdout,
+		Stderr:    rc.Stderr,
+		Vars:      args.Vars,
+		VarFiles:  args.VarFiles,
+		InitState: args.InitState,
+	})
+}
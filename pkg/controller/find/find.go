@@ -2,20 +2,27 @@ package find
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"io"
 	"log/slog"
 	"maps"
 	"path/filepath"
 	"slices"
-	"strings"
 
 	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/go-error-with-exit-code/ecerror"
 	"github.com/suzuki-shunsuke/slog-error/slogerr"
+	"github.com/suzuki-shunsuke/tfrstate/pkg/tfrstate"
+	"github.com/zclconf/go-cty/cty"
 )
 
 type Param struct {
-	Format    string
+	Format string
+	// OutputVersion is the version of the JSON output format.
+	// 1 outputs a bare array of changes and 2 outputs an envelope including metadata.
+	OutputVersion int
+	// Version is the version of tfrstate.
+	Version   string
 	PlanFile  string
 	Dir       string
 	Root      string
@@ -24,8 +31,31 @@ type Param struct {
 	Key       string
 	GCSBucket string
 	GCSPrefix string
-	Outputs   []string
-	Stdout    io.Writer
+	// S3Endpoint is a custom S3 endpoint such as MinIO.
+	S3Endpoint string
+	// S3Region is the AWS Region of the S3 Bucket.
+	S3Region string
+	Outputs  []string
+	Stdout   io.Writer
+	// Stderr is a writer to output diagnostics.
+	Stderr   io.Writer
+	Markdown *MarkdownOption
+	// Vars are variables given by --var. They are used to resolve configurations of terraform_remote_state and backend.
+	Vars []string
+	// VarFiles are variable definitions files given by --var-file.
+	VarFiles []string
+	// BackendConfigs are partial backend configurations given by --backend-config.
+	// Each configuration is either a file path or a pair of key and value separated by "=".
+	BackendConfigs []string
+	// InitState decides how .terraform/terraform.tfstate in Dir is used. One of fallback, prefer, and ignore.
+	InitState string
+	// ExitCode makes the command exit with ExitCodeChanges if some consumers are affected.
+	ExitCode bool
+	// Strict makes the command fail if some terraform_remote_state data sources can't be resolved.
+	Strict bool
+	// Registry is a set of supported backend types.
+	// If Registry is nil, built-in backend types are supported.
+	Registry *tfrstate.Registry
 }
 
 type FileWithBackend struct {
@@ -34,181 +64,195 @@ type FileWithBackend struct {
 
 type TerraformBlock struct{}
 
-type File struct {
-	Path    string
-	Content string
-	Byte    []byte
-}
-
-type Dir struct {
-	Path   string
-	Files  []*File
-	States []*RemoteState
-}
-
-func Find(_ context.Context, logger *slog.Logger, afs afero.Fs, param *Param) error { //nolint:funlen,cyclop
-	bucket := &Bucket{
-		Bucket: param.Bucket,
-		Key:    param.Key,
-		Prefix: param.GCSPrefix,
+func Find(ctx context.Context, logger *slog.Logger, afs afero.Fs, param *Param) error { //nolint:cyclop
+	if err := validateOutputVersion(param); err != nil {
+		return err
+	}
+	registry := param.Registry
+	if registry == nil {
+		registry = tfrstate.NewRegistry()
 	}
-	if param.Bucket != "" {
-		bucket.Type = backendTypeS3
+	bucket, err := flagBucket(registry, param)
+	if err != nil {
+		return err
 	}
 	// parse plan file and extract changed outputs
-	if param.GCSBucket != "" {
-		bucket.Bucket = param.GCSBucket
-		bucket.Type = backendTypeGCS
+	changedOutputs := make(map[string]string, len(param.Outputs))
+	for _, name := range param.Outputs {
+		changedOutputs[name] = ""
 	}
-	changedOutputs := param.Outputs
+	// renamedOutputs is a map of old output names and new output names inferred from the plan file.
+	var renamedOutputs map[string]string
 	if param.PlanFile != "" {
-		arr, err := extractChangedOutputs(afs, param.PlanFile)
+		outputs, err := tfrstate.ReadPlanOutputs(afs, param.PlanFile)
 		if err != nil {
-			return err
+			return err //nolint:wrapcheck
 		}
-		if len(arr) == 0 {
+		if len(outputs.Changed) == 0 {
 			logger.Info("no output changes")
+			if param.OutputVersion == outputVersion2 {
+				// The envelope is always output so that consumers can parse stdout
+				return outputJSONV2(param, &tfrstate.Result{}, nil, nil)
+			}
 			return nil
 		}
-		changedOutputs = arr
+		changedOutputs = outputs.Changed
+		renamedOutputs = outputs.Renamed
+		for _, from := range slices.Sorted(maps.Keys(renamedOutputs)) {
+			logger.Info("the output is probably renamed", "from", from, "to", renamedOutputs[from])
+		}
 	}
 
-	if bucket.Bucket == "" {
-		// parse HCLs in dir and extract backend configurations
-		if err := findBackendConfig(logger, afs, param.Dir, bucket); err != nil {
-			return err
-		}
+	vars, err := tfrstate.ParseVars(param.Vars)
+	if err != nil {
+		return err
 	}
 
-	if bucket.Type == "" {
-		logger.Info("no backend configuration")
-		return nil
+	opts := &tfrstate.Options{
+		WorkDir:        param.PWD,
+		BaseDir:        param.Root,
+		Backend:        bucket,
+		BackendDir:     param.Dir,
+		BackendConfigs: param.BackendConfigs,
+		InitState:      param.InitState,
+		ChangedOutputs: changedOutputs,
+		RenamedOutputs: renamedOutputs,
+		Vars:           vars,
+		VarFiles:       param.VarFiles,
+		Registry:       registry,
+	}
+	var w rowWriter
+	if isStreamFormat(param.Format) {
+		// Output references as soon as they are found
+		w, err = newRowWriter(param.Stdout, param.Format)
+		if err != nil {
+			return err
+		}
+		opts.OnReferences = func(dir, file string, refs []*tfrstate.Reference) error {
+			for _, ref := range refs {
+				if err := w.WriteRow(newRow(dir, file, ref)); err != nil {
+					return err
+				}
+			}
+			return w.Flush()
+		}
 	}
-	logger.Debug("backend configuration", bucket.LogAttrs()...)
 
-	// find HCLs in base directories and list directories where changed outputs are used
-	tfFiles, err := findTFFiles(afs, param.Root)
+	result, err := tfrstate.Find(ctx, logger, afs, opts)
 	if err != nil {
-		return err
+		return err //nolint:wrapcheck
 	}
-	logger.Debug("Found *.tf files", "num_of_files", len(tfFiles))
-	dirs := map[string]*Dir{}
-	// Find files including a string "terraform_remote_state"
-	if err := filterFilesWithRemoteState(afs, tfFiles, dirs); err != nil {
-		return err
+	if err := tfrstate.WriteDiagnostics(param.Stderr, afs, result.Diagnostics); err != nil {
+		return err //nolint:wrapcheck
 	}
-
-	// Find terraform_remote_state data sources.
-	for _, dir := range dirs {
-		for _, file := range dir.Files {
-			logger := logger.With("file", file.Path)
-			logger.Debug("terraform_remote_state is found")
-			remoteStates, err := extractRemoteStates(logger, file.Byte, file.Path, bucket)
-			if err != nil {
-				slogerr.WithError(logger, err).Warn("extract terraform_remote_state")
-				continue
+	if result.Backend == nil {
+		logger.Info("no backend configuration")
+		if param.OutputVersion == outputVersion2 {
+			// The envelope is output so that consumers can read diagnostics
+			if err := outputJSONV2(param, result, changedOutputs, renamedOutputs); err != nil {
+				return err
 			}
-			dir.States = append(dir.States, remoteStates...)
 		}
+		return checkStrict(param, result)
+	}
+	if w != nil {
+		if err := writeUnresolvedRows(w, result.Unresolved); err != nil {
+			return err
+		}
+		if err := checkStrict(param, result); err != nil {
+			return err
+		}
+		return checkChanges(param, result.Changes)
 	}
 
-	// Find attributes where changed outputs are used
-	// data.terraform_remote_state.<name>.outputs.<output_name>
-	// directory -> file -> changed outputs
-	changed := map[string]map[string]map[string]struct{}{}
-	findCaller(dirs, changedOutputs, changed)
-	// Format the result to output as JSON
-	changes, err := toChanges(param.PWD, param.Root, changed)
-	if err != nil {
+	// Output the result
+	repoRoot := findRepoRoot(afs, result.BaseDir)
+	if repoRoot == "" {
+		repoRoot = param.PWD
+	}
+	if err := output(param, &Result{
+		Result:         result,
+		RepoRoot:       repoRoot,
+		ChangedOutputs: changedOutputs,
+		RenamedOutputs: renamedOutputs,
+	}); err != nil {
 		return err
 	}
-	// Output the result
-	if err := output(changes, param.Stdout, param.Format); err != nil {
+	if err := checkStrict(param, result); err != nil {
 		return err
 	}
-	return nil
+	return checkChanges(param, result.Changes)
 }
 
-func toChanges(pwd, baseDir string, changed map[string]map[string]map[string]struct{}) ([]*Change, error) {
-	changes := make([]*Change, 0, len(changed))
-	// baseDir is an absolute path or a relative path from the current directory
-	if !filepath.IsAbs(baseDir) {
-		baseDir = filepath.Join(pwd, baseDir)
-	}
-	for dir, m := range changed {
-		// convert dir to the relative path from the base directory
-		// dir is an absolute path or a relative path from the current directory
-		absDir := dir
-		if !filepath.IsAbs(dir) {
-			absDir = filepath.Join(pwd, dir)
-		}
-		dir, err := filepath.Rel(baseDir, absDir)
-		if err != nil {
-			return nil, fmt.Errorf("get a relative path from baseDir to dir: %w", err)
-		}
-		files := make([]*ChangedFile, 0, len(m))
-		for file, outputs := range m {
-			// convert file to the relative path from dir
-			// file is an absolute path or a relative path from the current directory
-			if !filepath.IsAbs(file) {
-				file = filepath.Join(pwd, file)
-			}
-			file, err := filepath.Rel(absDir, file)
-			if err != nil {
-				return nil, fmt.Errorf("get a relative path from baseDir to file: %w", err)
-			}
-			files = append(files, &ChangedFile{
-				Path:    file,
-				Outputs: slices.Sorted(maps.Keys(outputs)),
-			})
-		}
-		changes = append(changes, &Change{
-			Dir:   dir,
-			Files: files,
-		})
+// flagBucket returns the backend configuration given by command line flags.
+// If the backend isn't given by flags, it returns nil.
+func flagBucket(registry *tfrstate.Registry, param *Param) (*tfrstate.Bucket, error) {
+	switch {
+	case param.GCSBucket != "":
+		return registry.Bucket(tfrstate.BackendTypeGCS, map[string]cty.Value{ //nolint:wrapcheck
+			"bucket": cty.StringVal(param.GCSBucket),
+			"prefix": cty.StringVal(param.GCSPrefix),
+		}, param.PWD)
+	case param.Bucket != "":
+		return registry.Bucket(tfrstate.BackendTypeS3, map[string]cty.Value{ //nolint:wrapcheck
+			"bucket": cty.StringVal(param.Bucket),
+			"key":    cty.StringVal(param.Key),
+			"endpoints": cty.ObjectVal(map[string]cty.Value{
+				"s3": cty.StringVal(param.S3Endpoint),
+			}),
+			"region": cty.StringVal(param.S3Region),
+		}, param.PWD)
 	}
-	return changes, nil
+	return nil, nil //nolint:nilnil
 }
 
-func filterFilesWithRemoteState(afs afero.Fs, tfFiles []string, dirs map[string]*Dir) error {
-	for _, matchFile := range tfFiles {
-		// Find files including a string "terraform_remote_state"
-		b, err := afero.ReadFile(afs, matchFile)
-		if err != nil {
-			return fmt.Errorf("read a file: %w", slogerr.With(err, "file", matchFile))
-		}
-		s := string(b)
-		if !strings.Contains(s, "terraform_remote_state") {
-			continue
-		}
-		dirPath := filepath.Dir(matchFile)
-		dir, ok := dirs[dirPath]
-		if !ok {
-			dir = &Dir{
-				Path: dirPath,
-			}
-		}
-		dir.Files = append(dir.Files, &File{
-			Path:    matchFile,
-			Content: s,
-			Byte:    b,
-		})
-		dirs[dirPath] = dir
+// checkStrict returns an error if param.Strict is true and some consumers can't be resolved or analyzed.
+// Consumers lacking required attributes such as backend and config are reported as error diagnostics.
+func checkStrict(param *Param, result *tfrstate.Result) error {
+	if !param.Strict {
+		return nil
+	}
+	if len(result.Unresolved) != 0 {
+		return slogerr.With(errors.New("some consumers can't be resolved"), "num_of_unresolved", len(result.Unresolved)) //nolint:wrapcheck
+	}
+	if tfrstate.HasErrors(result.Diagnostics) {
+		return slogerr.With(errors.New("some files can't be analyzed"), "num_of_diagnostics", len(result.Diagnostics)) //nolint:wrapcheck
 	}
 	return nil
 }
 
-type Change struct {
-	Dir   string         `json:"dir"`
-	Files []*ChangedFile `json:"files"`
+// ExitCodeChanges is the exit code when some consumers are affected and --exit-code is set.
+// Like terraform plan -detailed-exitcode, 0 means no impact and 1 means an error.
+const ExitCodeChanges = 2
+
+// checkChanges returns an error with ExitCodeChanges if param.ExitCode is true and some consumers are affected.
+// The error has no message so that it isn't logged as a failure.
+func checkChanges(param *Param, changes []*tfrstate.Change) error {
+	if !param.ExitCode || len(changes) == 0 {
+		return nil
+	}
+	return ecerror.Wrap(nil, ExitCodeChanges) //nolint:wrapcheck
 }
 
-type ChangedFile struct {
-	Path    string   `json:"path"`
-	Outputs []string `json:"outputs"`
+// Result is the result of the find command.
+type Result struct {
+	*tfrstate.Result
+	// RepoRoot is the absolute path of the root directory of the Git repository.
+	// If the base directory isn't in any Git repository, it's the current directory.
+	RepoRoot string
+	// ChangedOutputs is a map of changed output names and their change kinds.
+	ChangedOutputs map[string]string
+	// RenamedOutputs is a map of old output names and new output names inferred from the plan file.
+	RenamedOutputs map[string]string
 }
 
-type RemoteState struct {
-	Name string
-	File string
+// repoPath returns the slash separated path of a file from the repository root.
+// dir is a relative path from the base directory and file is a relative path from dir.
+func (r *Result) repoPath(dir, file string) string {
+	p := filepath.Join(r.BaseDir, dir, file)
+	rel, err := filepath.Rel(r.RepoRoot, p)
+	if err != nil {
+		return filepath.ToSlash(filepath.Join(dir, file))
+	}
+	return filepath.ToSlash(rel)
 }
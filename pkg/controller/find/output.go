@@ -5,34 +5,56 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"strings"
+
+	"github.com/suzuki-shunsuke/tfrstate/pkg/tfrstate"
 )
 
-func output(changes []*Change, stdout io.Writer, format string) error {
-	switch format {
+func output(param *Param, result *Result) error {
+	stdout := param.Stdout
+	switch param.Format {
 	case "json":
-		encoder := json.NewEncoder(stdout)
-		encoder.SetIndent("", "  ")
-		if err := encoder.Encode(changes); err != nil {
-			return fmt.Errorf("encode the result as JSON: %w", err)
+		if param.OutputVersion == outputVersion2 {
+			return outputJSONV2(param, result.Result, result.ChangedOutputs, result.RenamedOutputs)
 		}
-		return nil
+		return encodeJSON(stdout, toChangesV1(result.Changes))
 	case "markdown":
-		if len(changes) == 0 {
-			// No output
-			return nil
-		}
-		lines := []string{
-			"dir | file | outputs",
-			"--- | --- | ---",
-		}
-		for _, change := range changes {
-			for _, file := range change.Files {
-				lines = append(lines, fmt.Sprintf("%s | %s | %s", change.Dir, file.Path, strings.Join(file.Outputs, ", ")))
-			}
-		}
-		fmt.Fprintln(stdout, strings.Join(lines, "\n"))
-		return nil
+		return outputMarkdown(stdout, param.Markdown, result)
+	case "sarif":
+		return outputSARIF(stdout, result)
+	case "github-actions":
+		return outputGitHubActions(stdout, result)
+	case "junit":
+		return outputJUnit(stdout, result, param.Strict)
 	}
 	return errors.New("unsupported format")
 }
+
+// encodeJSON outputs v as indented JSON.
+func encodeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("encode the result as JSON: %w", err)
+	}
+	return nil
+}
+
+// unresolvedMessage returns a message describing a consumer which can't be resolved.
+func unresolvedMessage(u *tfrstate.Unresolved) string {
+	return fmt.Sprintf("%s can't be resolved statically: %s", u.Address(), u.Reason)
+}
+
+// referenceMessage returns a message describing a reference to a changed output.
+func referenceMessage(backend *tfrstate.Bucket, ref *tfrstate.Reference) string {
+	kind := "changed"
+	switch ref.Change {
+	case tfrstate.ChangeKindRemoved:
+		kind = "removed"
+	case tfrstate.ChangeKindRenamed:
+		if ref.RenamedTo != "" {
+			return fmt.Sprintf("%s refers to the output %s of the Terraform State (%s), which is probably renamed to %s. Use %s instead", ref.Address, ref.Output, backend, ref.RenamedTo, ref.Suggestion)
+		}
+		kind = "renamed"
+	}
+	return fmt.Sprintf("%s refers to the output %s of the Terraform State (%s), which is %s", ref.Address, ref.Output, backend, kind)
+}
@@ -0,0 +1,62 @@
+package find
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/suzuki-shunsuke/tfrstate/pkg/tfrstate"
+)
+
+// outputGitHubActions outputs the result as GitHub Actions workflow commands.
+// https://docs.github.com/en/actions/reference/workflow-commands-for-github-actions
+//
+//	::warning file=foo/main.tf,line=1,col=1,endLine=1,endColumn=10,title=...::message
+func outputGitHubActions(stdout io.Writer, result *Result) error {
+	for _, change := range result.Changes {
+		for _, file := range change.Files {
+			path := result.repoPath(change.Dir, file.Path)
+			for _, ref := range file.References {
+				command := "warning"
+				title := "tfrstate: output is changed"
+				switch ref.Change {
+				case tfrstate.ChangeKindRemoved:
+					command = "error"
+					title = "tfrstate: output is removed"
+				case tfrstate.ChangeKindRenamed:
+					command = "error"
+					title = "tfrstate: output is renamed"
+				}
+				if _, err := fmt.Fprintln(stdout, githubActionsCommand(command, title, path, ref.Range, referenceMessage(result.Backend, ref))); err != nil {
+					return fmt.Errorf("output a workflow command: %w", err)
+				}
+			}
+		}
+	}
+	for _, u := range result.Unresolved {
+		if _, err := fmt.Fprintln(stdout, githubActionsCommand("warning", "tfrstate: "+u.Kind+" can't be resolved", result.repoPath(u.Dir, u.File), u.Range, unresolvedMessage(u))); err != nil {
+			return fmt.Errorf("output a workflow command: %w", err)
+		}
+	}
+	return nil
+}
+
+func githubActionsCommand(command, title, path string, rng *tfrstate.Range, msg string) string {
+	props := []string{
+		"file=" + escapeGitHubActionsProperty(path),
+		fmt.Sprintf("line=%d", rng.Line),
+		fmt.Sprintf("col=%d", rng.Column),
+		fmt.Sprintf("endLine=%d", rng.EndLine),
+		fmt.Sprintf("endColumn=%d", rng.EndColumn),
+		"title=" + escapeGitHubActionsProperty(title),
+	}
+	return fmt.Sprintf("::%s %s::%s", command, strings.Join(props, ","), escapeGitHubActionsData(msg))
+}
+
+func escapeGitHubActionsData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func escapeGitHubActionsProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,134 @@
+package find
+
+import (
+	"errors"
+	"maps"
+	"slices"
+
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+	"github.com/suzuki-shunsuke/tfrstate/pkg/tfrstate"
+)
+
+const (
+	outputVersion1 = 1
+	outputVersion2 = 2
+)
+
+// OutputV2 is the JSON output with --output-version 2.
+// The JSON Schema is pkg/schema/output-v2.json.
+type OutputV2 struct {
+	OutputVersion   int    `json:"output_version"`
+	TfrstateVersion string `json:"tfrstate_version"`
+	// Backend is the normalized backend of the producer Terraform State.
+	// It's nil if the backend isn't found.
+	Backend        *tfrstate.Bucket       `json:"backend"`
+	ChangedOutputs []*ChangedOutput       `json:"changed_outputs"`
+	Changes        []*tfrstate.Change     `json:"changes"`
+	Unresolved     []*tfrstate.Unresolved `json:"unresolved"`
+	Diagnostics    []*tfrstate.Diagnostic `json:"diagnostics"`
+}
+
+// ChangedOutput is a changed output of the producer Terraform State.
+type ChangedOutput struct {
+	Name string `json:"name"`
+	// Change is the change kind such as "removed". It's empty if it's unknown.
+	Change string `json:"change,omitempty"`
+	// RenamedTo is the new output name if the output is probably renamed.
+	RenamedTo string `json:"renamed_to,omitempty"`
+}
+
+// changeV1 is a change of the default JSON output.
+// It's kept compatible with the output before --output-version 2 was added,
+// so references aren't included and outputs is null if any outputs are searched.
+type changeV1 struct {
+	Dir   string           `json:"dir"`
+	Files []*changedFileV1 `json:"files"`
+}
+
+type changedFileV1 struct {
+	Path    string   `json:"path"`
+	Outputs []string `json:"outputs"`
+}
+
+func toChangesV1(changes []*tfrstate.Change) []*changeV1 {
+	ret := make([]*changeV1, len(changes))
+	for i, change := range changes {
+		files := make([]*changedFileV1, len(change.Files))
+		for j, file := range change.Files {
+			files[j] = &changedFileV1{
+				Path:    file.Path,
+				Outputs: file.Outputs,
+			}
+		}
+		ret[i] = &changeV1{
+			Dir:   change.Dir,
+			Files: files,
+		}
+	}
+	return ret
+}
+
+// toChangesV2 returns changes of the JSON output version 2.
+// outputs is an empty array instead of null because the JSON Schema requires an array.
+func toChangesV2(changes []*tfrstate.Change) []*tfrstate.Change {
+	ret := make([]*tfrstate.Change, len(changes))
+	for i, change := range changes {
+		files := make([]*tfrstate.ChangedFile, len(change.Files))
+		for j, file := range change.Files {
+			files[j] = &tfrstate.ChangedFile{
+				Path:       file.Path,
+				Outputs:    nonNil(file.Outputs),
+				References: nonNil(file.References),
+			}
+		}
+		ret[i] = &tfrstate.Change{
+			Dir:   change.Dir,
+			Files: files,
+		}
+	}
+	return ret
+}
+
+// validateOutputVersion validates --output-version.
+// The default value 0 is treated as 1.
+func validateOutputVersion(param *Param) error {
+	switch param.OutputVersion {
+	case 0, outputVersion1:
+		return nil
+	case outputVersion2:
+		if param.Format != "json" {
+			return slogerr.With(errors.New("--output-version 2 is supported only by the json output format"), "output_format", param.Format) //nolint:wrapcheck
+		}
+		return nil
+	}
+	return slogerr.With(errors.New("--output-version must be 1 or 2"), "output_version", param.OutputVersion) //nolint:wrapcheck
+}
+
+// outputJSONV2 outputs the result as the JSON envelope of --output-version 2.
+func outputJSONV2(param *Param, result *tfrstate.Result, changedOutputs, renamedOutputs map[string]string) error {
+	outputs := make([]*ChangedOutput, 0, len(changedOutputs))
+	for _, name := range slices.Sorted(maps.Keys(changedOutputs)) {
+		outputs = append(outputs, &ChangedOutput{
+			Name:      name,
+			Change:    changedOutputs[name],
+			RenamedTo: renamedOutputs[name],
+		})
+	}
+	// Arrays are output as [] instead of null
+	return encodeJSON(param.Stdout, &OutputV2{
+		OutputVersion:   outputVersion2,
+		TfrstateVersion: param.Version,
+		Backend:         result.Backend,
+		ChangedOutputs:  outputs,
+		Changes:         toChangesV2(result.Changes),
+		Unresolved:      nonNil(result.Unresolved),
+		Diagnostics:     nonNil(result.Diagnostics),
+	})
+}
+
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
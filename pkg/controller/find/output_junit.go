@@ -0,0 +1,143 @@
+package find
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/suzuki-shunsuke/tfrstate/pkg/tfrstate"
+)
+
+// JUnitTestSuites is the root element of a JUnit XML report.
+type JUnitTestSuites struct {
+	XMLName    xml.Name          `xml:"testsuites"`
+	Tests      int               `xml:"tests,attr"`
+	Failures   int               `xml:"failures,attr"`
+	Skipped    int               `xml:"skipped,attr"`
+	TestSuites []*JUnitTestSuite `xml:"testsuite"`
+}
+
+type JUnitTestSuite struct {
+	Name      string           `xml:"name,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	Skipped   int              `xml:"skipped,attr"`
+	TestCases []*JUnitTestCase `xml:"testcase"`
+}
+
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+	Skipped   *JUnitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type JUnitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// outputJUnit outputs the result as a JUnit XML report.
+// Each directory depending on changed outputs is a test case.
+// A test case fails if the directory refers to removed or renamed outputs.
+// Each consumer which can't be resolved is also a test case.
+// It fails if strict is true like --strict, and otherwise it's skipped.
+func outputJUnit(stdout io.Writer, result *Result, strict bool) error {
+	suite := &JUnitTestSuite{
+		Name:      "tfrstate",
+		TestCases: make([]*JUnitTestCase, 0, len(result.Changes)+len(result.Unresolved)),
+	}
+	for _, change := range result.Changes {
+		testCase, failed := newJUnitTestCase(result, change)
+		suite.TestCases = append(suite.TestCases, testCase)
+		suite.Tests++
+		if failed {
+			suite.Failures++
+		}
+	}
+	for _, u := range result.Unresolved {
+		suite.TestCases = append(suite.TestCases, newUnresolvedJUnitTestCase(result, u, strict))
+		suite.Tests++
+		if strict {
+			suite.Failures++
+		} else {
+			suite.Skipped++
+		}
+	}
+	report := &JUnitTestSuites{
+		Tests:      suite.Tests,
+		Failures:   suite.Failures,
+		Skipped:    suite.Skipped,
+		TestSuites: []*JUnitTestSuite{suite},
+	}
+	if _, err := io.WriteString(stdout, xml.Header); err != nil {
+		return fmt.Errorf("output the XML header: %w", err)
+	}
+	encoder := xml.NewEncoder(stdout)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("encode the result as JUnit XML: %w", err)
+	}
+	if _, err := fmt.Fprintln(stdout); err != nil {
+		return fmt.Errorf("output a newline: %w", err)
+	}
+	return nil
+}
+
+func newJUnitTestCase(result *Result, change *tfrstate.Change) (*JUnitTestCase, bool) {
+	lines := []string{}
+	removed := 0
+	for _, file := range change.Files {
+		path := result.repoPath(change.Dir, file.Path)
+		for _, ref := range file.References {
+			if ref.Breaking() {
+				removed++
+			}
+			lines = append(lines, fmt.Sprintf("%s:%d:%d: %s", path, ref.Range.Line, ref.Range.Column, referenceMessage(result.Backend, ref)))
+		}
+	}
+	testCase := &JUnitTestCase{
+		Name:      change.Dir,
+		ClassName: "tfrstate",
+	}
+	text := strings.Join(lines, "\n")
+	if removed == 0 {
+		testCase.SystemOut = text
+		return testCase, false
+	}
+	testCase.Failure = &JUnitFailure{
+		Message: fmt.Sprintf("%s refers to %d removed or renamed outputs of the Terraform State (%s)", change.Dir, removed, result.Backend),
+		Type:    ruleOutputRemoved,
+		Text:    text,
+	}
+	return testCase, true
+}
+
+func newUnresolvedJUnitTestCase(result *Result, u *tfrstate.Unresolved, strict bool) *JUnitTestCase {
+	message := unresolvedMessage(u)
+	text := fmt.Sprintf("%s:%d:%d: %s", result.repoPath(u.Dir, u.File), u.Range.Line, u.Range.Column, message)
+	testCase := &JUnitTestCase{
+		Name:      fmt.Sprintf("%s: %s", u.Dir, u.Address()),
+		ClassName: "tfrstate",
+	}
+	if !strict {
+		testCase.Skipped = &JUnitSkipped{
+			Message: message,
+		}
+		testCase.SystemOut = text
+		return testCase
+	}
+	testCase.Failure = &JUnitFailure{
+		Message: message,
+		Type:    ruleUnresolved,
+		Text:    text,
+	}
+	return testCase
+}
@@ -0,0 +1,177 @@
+package find
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/suzuki-shunsuke/tfrstate/pkg/tfrstate"
+)
+
+// MarkdownOption is options of the markdown output format.
+type MarkdownOption struct {
+	// LinkTemplate is a template of links to files.
+	// {repo}, {sha}, {path}, and {line} are replaced.
+	//
+	//	https://github.com/{repo}/blob/{sha}/{path}#L{line}
+	LinkTemplate string
+	Repo         string
+	SHA          string
+	// GroupByDir groups files by directory with <details> sections.
+	GroupByDir bool
+	// Summary outputs a summary header with counts and the backend configuration.
+	Summary bool
+}
+
+func outputMarkdown(stdout io.Writer, opt *MarkdownOption, result *Result) error {
+	if len(result.Changes) == 0 && len(result.Unresolved) == 0 {
+		// No output
+		return nil
+	}
+	if opt == nil {
+		opt = &MarkdownOption{}
+	}
+	lines := []string{}
+	if opt.Summary {
+		lines = append(lines, markdownSummary(result), "")
+	}
+	switch {
+	case len(result.Changes) == 0:
+	case opt.GroupByDir:
+		lines = append(lines, markdownGroupedChanges(opt, result)...)
+	default:
+		lines = append(lines, markdownChanges(opt, result)...)
+	}
+	if len(result.Unresolved) != 0 {
+		if len(result.Changes) != 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, markdownUnresolved(opt, result)...)
+	}
+	if _, err := fmt.Fprintln(stdout, strings.Join(lines, "\n")); err != nil {
+		return fmt.Errorf("output the result as markdown: %w", err)
+	}
+	return nil
+}
+
+func markdownChanges(opt *MarkdownOption, result *Result) []string {
+	lines := []string{
+		"dir | file | outputs",
+		"--- | --- | ---",
+	}
+	for _, change := range result.Changes {
+		for _, file := range change.Files {
+			lines = append(lines, fmt.Sprintf("%s | %s | %s", escapeMarkdownTable(change.Dir), markdownFile(opt, result, change, file), markdownOutputs(opt, result, change, file)))
+		}
+	}
+	return lines
+}
+
+func markdownGroupedChanges(opt *MarkdownOption, result *Result) []string {
+	lines := []string{}
+	for i, change := range result.Changes {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines,
+			"<details>",
+			fmt.Sprintf("<summary>%s (%d files)</summary>", escapeHTML(change.Dir), len(change.Files)),
+			"",
+			"file | outputs",
+			"--- | ---",
+		)
+		for _, file := range change.Files {
+			lines = append(lines, fmt.Sprintf("%s | %s", markdownFile(opt, result, change, file), markdownOutputs(opt, result, change, file)))
+		}
+		lines = append(lines, "", "</details>")
+	}
+	return lines
+}
+
+// markdownUnresolved returns a section listing consumers such as terraform_remote_state data sources which can't be resolved.
+func markdownUnresolved(opt *MarkdownOption, result *Result) []string {
+	lines := []string{
+		"### Unresolved consumers",
+		"",
+		"dir | file | consumer | reason",
+		"--- | --- | --- | ---",
+	}
+	for _, u := range result.Unresolved {
+		file := escapeMarkdownTable(u.File)
+		if opt.LinkTemplate != "" {
+			file = fmt.Sprintf("[%s](%s)", file, markdownLink(opt, result.repoPath(u.Dir, u.File), u.Range.Line))
+		}
+		lines = append(lines, fmt.Sprintf("%s | %s | %s | %s", escapeMarkdownTable(u.Dir), file, escapeMarkdownTable(u.Address()), escapeMarkdownTable(strings.ReplaceAll(u.Reason, "\n", " "))))
+	}
+	return lines
+}
+
+func markdownSummary(result *Result) string {
+	files := 0
+	refs := 0
+	removed := 0
+	for _, change := range result.Changes {
+		files += len(change.Files)
+		for _, file := range change.Files {
+			refs += len(file.References)
+			for _, ref := range file.References {
+				if ref.Breaking() {
+					removed++
+				}
+			}
+		}
+	}
+	return fmt.Sprintf(`## tfrstate
+
+Backend: %s
+
+- %d directories
+- %d files
+- %d references (%d references to removed or renamed outputs)
+- %d unresolved consumers`, "`"+result.Backend.String()+"`", len(result.Changes), files, refs, removed, len(result.Unresolved))
+}
+
+// markdownFile returns a file path linked to the first reference in the file.
+func markdownFile(opt *MarkdownOption, result *Result, change *tfrstate.Change, file *tfrstate.ChangedFile) string {
+	path := escapeMarkdownTable(file.Path)
+	if opt.LinkTemplate == "" || len(file.References) == 0 {
+		return path
+	}
+	return fmt.Sprintf("[%s](%s)", path, markdownLink(opt, result.repoPath(change.Dir, file.Path), file.References[0].Range.Line))
+}
+
+// markdownOutputs returns output names linked to the first reference to each output.
+func markdownOutputs(opt *MarkdownOption, result *Result, change *tfrstate.Change, file *tfrstate.ChangedFile) string {
+	outputs := make([]string, len(file.Outputs))
+	for i, output := range file.Outputs {
+		outputs[i] = escapeMarkdownTable(output)
+		if opt.LinkTemplate == "" {
+			continue
+		}
+		for _, ref := range file.References {
+			if ref.Output == output {
+				outputs[i] = fmt.Sprintf("[%s](%s)", outputs[i], markdownLink(opt, result.repoPath(change.Dir, file.Path), ref.Range.Line))
+				break
+			}
+		}
+	}
+	return strings.Join(outputs, ", ")
+}
+
+func markdownLink(opt *MarkdownOption, path string, line int) string {
+	return strings.NewReplacer(
+		"{repo}", opt.Repo,
+		"{sha}", opt.SHA,
+		"{path}", path,
+		"{line}", strconv.Itoa(line),
+	).Replace(opt.LinkTemplate)
+}
+
+func escapeMarkdownTable(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+func escapeHTML(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
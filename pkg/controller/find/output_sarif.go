@@ -0,0 +1,164 @@
+package find
+
+import (
+	"io"
+
+	"github.com/suzuki-shunsuke/tfrstate/pkg/tfrstate"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	ruleOutputChanged = "remote-state-output-changed"
+	ruleOutputRemoved = "remote-state-output-removed"
+	ruleUnresolved    = "remote-state-unresolved"
+)
+
+// SARIF is a minimal subset of SARIF 2.1.0 used by tfrstate.
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+type SARIF struct {
+	Version string      `json:"version"`
+	Schema  string      `json:"$schema"`
+	Runs    []*SARIFRun `json:"runs"`
+}
+
+type SARIFRun struct {
+	Tool    *SARIFTool     `json:"tool"`
+	Results []*SARIFResult `json:"results"`
+}
+
+type SARIFTool struct {
+	Driver *SARIFDriver `json:"driver"`
+}
+
+type SARIFDriver struct {
+	Name           string       `json:"name"`
+	InformationURI string       `json:"informationUri"`
+	Rules          []*SARIFRule `json:"rules"`
+}
+
+type SARIFRule struct {
+	ID                   string              `json:"id"`
+	ShortDescription     *SARIFMessage       `json:"shortDescription"`
+	DefaultConfiguration *SARIFConfiguration `json:"defaultConfiguration"`
+}
+
+type SARIFConfiguration struct {
+	Level string `json:"level"`
+}
+
+type SARIFMessage struct {
+	Text string `json:"text"`
+}
+
+type SARIFResult struct {
+	RuleID    string           `json:"ruleId"`
+	Level     string           `json:"level"`
+	Message   *SARIFMessage    `json:"message"`
+	Locations []*SARIFLocation `json:"locations"`
+}
+
+type SARIFLocation struct {
+	PhysicalLocation *SARIFPhysicalLocation `json:"physicalLocation"`
+}
+
+type SARIFPhysicalLocation struct {
+	ArtifactLocation *SARIFArtifactLocation `json:"artifactLocation"`
+	Region           *SARIFRegion           `json:"region"`
+}
+
+type SARIFArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type SARIFRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+}
+
+func outputSARIF(stdout io.Writer, result *Result) error {
+	results := []*SARIFResult{}
+	for _, change := range result.Changes {
+		for _, file := range change.Files {
+			uri := result.repoPath(change.Dir, file.Path)
+			for _, ref := range file.References {
+				results = append(results, newSARIFResult(result.Backend, uri, ref))
+			}
+		}
+	}
+	for _, u := range result.Unresolved {
+		results = append(results, &SARIFResult{
+			RuleID:    ruleUnresolved,
+			Level:     "warning",
+			Message:   &SARIFMessage{Text: unresolvedMessage(u)},
+			Locations: newSARIFLocations(result.repoPath(u.Dir, u.File), u.Range),
+		})
+	}
+	log := &SARIF{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []*SARIFRun{
+			{
+				Tool: &SARIFTool{
+					Driver: &SARIFDriver{
+						Name:           "tfrstate",
+						InformationURI: "https://github.com/suzuki-shunsuke/tfrstate",
+						Rules: []*SARIFRule{
+							{
+								ID:                   ruleOutputChanged,
+								ShortDescription:     &SARIFMessage{Text: "An output of the Terraform State referred by a consumer is changed"},
+								DefaultConfiguration: &SARIFConfiguration{Level: "warning"},
+							},
+							{
+								ID:                   ruleOutputRemoved,
+								ShortDescription:     &SARIFMessage{Text: "An output of the Terraform State referred by a consumer is removed or renamed"},
+								DefaultConfiguration: &SARIFConfiguration{Level: "error"},
+							},
+							{
+								ID:                   ruleUnresolved,
+								ShortDescription:     &SARIFMessage{Text: "The configuration of a consumer can't be resolved statically"},
+								DefaultConfiguration: &SARIFConfiguration{Level: "warning"},
+							},
+						},
+					},
+				},
+				Results: results,
+			},
+		},
+	}
+	return encodeJSON(stdout, log)
+}
+
+func newSARIFResult(backend *tfrstate.Bucket, uri string, ref *tfrstate.Reference) *SARIFResult {
+	ruleID := ruleOutputChanged
+	level := "warning"
+	if ref.Breaking() {
+		ruleID = ruleOutputRemoved
+		level = "error"
+	}
+	return &SARIFResult{
+		RuleID:    ruleID,
+		Level:     level,
+		Message:   &SARIFMessage{Text: referenceMessage(backend, ref)},
+		Locations: newSARIFLocations(uri, ref.Range),
+	}
+}
+
+func newSARIFLocations(uri string, rng *tfrstate.Range) []*SARIFLocation {
+	return []*SARIFLocation{
+		{
+			PhysicalLocation: &SARIFPhysicalLocation{
+				ArtifactLocation: &SARIFArtifactLocation{URI: uri},
+				Region: &SARIFRegion{
+					StartLine:   rng.Line,
+					StartColumn: rng.Column,
+					EndLine:     rng.EndLine,
+					EndColumn:   rng.EndColumn,
+				},
+			},
+		},
+	}
+}
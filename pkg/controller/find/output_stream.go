@@ -0,0 +1,169 @@
+package find
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/suzuki-shunsuke/tfrstate/pkg/tfrstate"
+)
+
+// Row is a flattened reference to a changed output or a consumer which can't be resolved.
+// Each row corresponds to a pair of (dir, file, output, reference location).
+type Row struct {
+	Dir         string `json:"dir"`
+	File        string `json:"file"`
+	Output      string `json:"output"`
+	DataSource  string `json:"data_source"`
+	InstanceKey string `json:"instance_key"`
+	Address     string `json:"address"`
+	Change      string `json:"change"`
+	Line        int    `json:"line"`
+	Column      int    `json:"column"`
+	EndLine     int    `json:"end_line"`
+	EndColumn   int    `json:"end_column"`
+	Kind        string `json:"kind"`
+}
+
+func newRow(dir, file string, ref *tfrstate.Reference) *Row {
+	return &Row{
+		Dir:         dir,
+		File:        file,
+		Output:      ref.Output,
+		DataSource:  ref.DataSource,
+		InstanceKey: ref.InstanceKey,
+		Address:     ref.Address,
+		Change:      ref.Change,
+		Line:        ref.Range.Line,
+		Column:      ref.Range.Column,
+		EndLine:     ref.Range.EndLine,
+		EndColumn:   ref.Range.EndColumn,
+		Kind:        ref.Kind,
+	}
+}
+
+// changeUnresolved is the change of rows of consumers which can't be resolved.
+const changeUnresolved = "unresolved"
+
+// newUnresolvedRow creates a row of a consumer which can't be resolved.
+// Output is empty because it's unknown which outputs the consumer refers.
+func newUnresolvedRow(u *tfrstate.Unresolved) *Row {
+	return &Row{
+		Dir:        u.Dir,
+		File:       u.File,
+		DataSource: u.Name,
+		Address:    u.Address(),
+		Change:     changeUnresolved,
+		Line:       u.Range.Line,
+		Column:     u.Range.Column,
+		EndLine:    u.Range.EndLine,
+		EndColumn:  u.Range.EndColumn,
+		Kind:       u.Kind,
+	}
+}
+
+// writeUnresolvedRows writes rows of consumers which can't be resolved.
+func writeUnresolvedRows(w rowWriter, unresolved []*tfrstate.Unresolved) error {
+	for _, u := range unresolved {
+		if err := w.WriteRow(newUnresolvedRow(u)); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// rowWriter writes rows as soon as they are found, without buffering the whole result.
+type rowWriter interface {
+	WriteRow(row *Row) error
+	Flush() error
+}
+
+func isStreamFormat(format string) bool {
+	return format == "csv" || format == "jsonl"
+}
+
+func newRowWriter(stdout io.Writer, format string) (rowWriter, error) {
+	switch format {
+	case "csv":
+		return newCSVWriter(stdout)
+	case "jsonl":
+		return &jsonlWriter{encoder: json.NewEncoder(stdout)}, nil
+	}
+	return nil, errors.New("unsupported format")
+}
+
+var csvHeader = []string{ //nolint:gochecknoglobals
+	"dir",
+	"file",
+	"output",
+	"data_source",
+	"instance_key",
+	"address",
+	"change",
+	"line",
+	"column",
+	"end_line",
+	"end_column",
+	"kind",
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func newCSVWriter(stdout io.Writer) (*csvWriter, error) {
+	w := &csvWriter{writer: csv.NewWriter(stdout)}
+	if err := w.writer.Write(csvHeader); err != nil {
+		return nil, fmt.Errorf("write a CSV header: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *csvWriter) WriteRow(row *Row) error {
+	if err := w.writer.Write([]string{
+		row.Dir,
+		row.File,
+		row.Output,
+		row.DataSource,
+		row.InstanceKey,
+		row.Address,
+		row.Change,
+		strconv.Itoa(row.Line),
+		strconv.Itoa(row.Column),
+		strconv.Itoa(row.EndLine),
+		strconv.Itoa(row.EndColumn),
+		row.Kind,
+	}); err != nil {
+		return fmt.Errorf("write a CSV row: %w", err)
+	}
+	return nil
+}
+
+func (w *csvWriter) Flush() error {
+	w.writer.Flush()
+	if err := w.writer.Error(); err != nil {
+		return fmt.Errorf("flush CSV rows: %w", err)
+	}
+	return nil
+}
+
+type jsonlWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *jsonlWriter) WriteRow(row *Row) error {
+	if err := w.encoder.Encode(row); err != nil {
+		return fmt.Errorf("encode a row as JSON: %w", err)
+	}
+	return nil
+}
+
+func (w *jsonlWriter) Flush() error {
+	return nil
+}
@@ -0,0 +1,23 @@
+package find
+
+import (
+	"path/filepath"
+
+	"github.com/spf13/afero"
+)
+
+// findRepoRoot returns the root directory of the Git repository including dir.
+// dir must be an absolute path.
+// If dir isn't in any Git repository, it returns an empty string.
+func findRepoRoot(afs afero.Fs, dir string) string {
+	for {
+		if _, err := afs.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
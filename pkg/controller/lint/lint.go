@@ -0,0 +1,65 @@
+package lint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+	"github.com/suzuki-shunsuke/tfrstate/pkg/tfrstate"
+)
+
+type Param struct {
+	// Format is the output format. Either "text" or "json".
+	Format string
+	Root   string
+	PWD    string
+	Stdout io.Writer
+	// Stderr is a writer to output diagnostics in the text format.
+	Stderr io.Writer
+	// Vars are variables given by --var.
+	Vars []string
+	// VarFiles are variable definitions files given by --var-file.
+	VarFiles []string
+	// InitState decides how .terraform/terraform.tfstate is used. One of fallback, prefer, and ignore.
+	InitState string
+}
+
+// Lint validates the config of terraform_remote_state data sources against backends of producers.
+// It fails if some error diagnostics are found.
+func Lint(ctx context.Context, logger *slog.Logger, afs afero.Fs, param *Param) error {
+	if param.Format != "text" && param.Format != "json" {
+		return slogerr.With(errors.New("unsupported format"), "output_format", param.Format) //nolint:wrapcheck
+	}
+	vars, err := tfrstate.ParseVars(param.Vars)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	result, err := tfrstate.Lint(ctx, logger, afs, &tfrstate.LintOptions{
+		WorkDir:   param.PWD,
+		BaseDir:   param.Root,
+		Vars:      vars,
+		VarFiles:  param.VarFiles,
+		InitState: param.InitState,
+	})
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	if param.Format == "json" {
+		encoder := json.NewEncoder(param.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result); err != nil {
+			return fmt.Errorf("encode the result as JSON: %w", err)
+		}
+	} else if err := tfrstate.WriteDiagnostics(param.Stderr, afs, result.Diagnostics); err != nil {
+		return err //nolint:wrapcheck
+	}
+	if tfrstate.HasErrors(result.Diagnostics) {
+		return slogerr.With(errors.New("some terraform_remote_state data sources have problems"), "num_of_diagnostics", len(result.Diagnostics)) //nolint:wrapcheck
+	}
+	return nil
+}
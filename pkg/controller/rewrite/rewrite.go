@@ -0,0 +1,115 @@
+package rewrite
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+	"github.com/suzuki-shunsuke/tfrstate/pkg/tfrstate"
+)
+
+type Param struct {
+	// From is the old output name.
+	From string
+	// To is the new output name.
+	To   string
+	Dir  string
+	Root string
+	PWD  string
+	// DryRun outputs a unified diff instead of rewriting files.
+	DryRun bool
+	Stdout io.Writer
+	// Stderr is a writer to output diagnostics.
+	Stderr io.Writer
+	// Vars are variables given by --var.
+	Vars []string
+	// VarFiles are variable definitions files given by --var-file.
+	VarFiles []string
+	// BackendConfigs are partial backend configurations given by --backend-config.
+	BackendConfigs []string
+	// InitState decides how .terraform/terraform.tfstate in Dir is used. One of fallback, prefer, and ignore.
+	InitState string
+}
+
+// Rewrite renames an output in references of consumers of the producer in param.Dir.
+func Rewrite(ctx context.Context, logger *slog.Logger, afs afero.Fs, param *Param) error {
+	vars, err := tfrstate.ParseVars(param.Vars)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	result, err := tfrstate.Rewrite(ctx, logger, afs, &tfrstate.RewriteOptions{
+		Options: tfrstate.Options{
+			WorkDir:        param.PWD,
+			BaseDir:        param.Root,
+			BackendDir:     param.Dir,
+			BackendConfigs: param.BackendConfigs,
+			InitState:      param.InitState,
+			Vars:           vars,
+			VarFiles:       param.VarFiles,
+		},
+		From: param.From,
+		To:   param.To,
+	})
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	if err := tfrstate.WriteDiagnostics(param.Stderr, afs, result.Diagnostics); err != nil {
+		return err //nolint:wrapcheck
+	}
+	if result.Backend == nil {
+		logger.Info("no backend configuration")
+		return nil
+	}
+	if len(result.Unresolved) > 0 {
+		logger.Warn("some consumers can't be resolved, so references in them aren't rewritten", "num_of_unresolved", len(result.Unresolved))
+	}
+	for _, file := range result.Files {
+		if param.DryRun {
+			if err := outputDiff(param.Stdout, file); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := writeFile(afs, file); err != nil {
+			return err
+		}
+		logger.Info("rewrite references", "file", filepath.Join(file.Dir, file.File), "num_of_references", len(file.References))
+	}
+	return nil
+}
+
+// outputDiff outputs the change of a file as a unified diff.
+func outputDiff(w io.Writer, file *tfrstate.RewrittenFile) error {
+	p := filepath.ToSlash(filepath.Join(file.Dir, file.File))
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(file.Before)),
+		B:        difflib.SplitLines(string(file.After)),
+		FromFile: "a/" + p,
+		ToFile:   "b/" + p,
+		Context:  3, //nolint:mnd
+	})
+	if err != nil {
+		return fmt.Errorf("generate a diff: %w", slogerr.With(err, "file", p))
+	}
+	if _, err := io.WriteString(w, diff); err != nil {
+		return fmt.Errorf("output a diff: %w", err)
+	}
+	return nil
+}
+
+// writeFile writes a rewritten file keeping the file mode.
+func writeFile(afs afero.Fs, file *tfrstate.RewrittenFile) error {
+	stat, err := afs.Stat(file.Path)
+	if err != nil {
+		return fmt.Errorf("get the file mode: %w", slogerr.With(err, "file", file.Path))
+	}
+	if err := afero.WriteFile(afs, file.Path, file.After, stat.Mode()); err != nil {
+		return fmt.Errorf("write a file: %w", slogerr.With(err, "file", file.Path))
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package unused
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+	"github.com/suzuki-shunsuke/tfrstate/pkg/tfrstate"
+)
+
+type Param struct {
+	// Format is the output format. Either "text" or "json".
+	Format string
+	Root   string
+	PWD    string
+	Stdout io.Writer
+	// Stderr is a writer to output diagnostics.
+	Stderr io.Writer
+	// Vars are variables given by --var.
+	Vars []string
+	// VarFiles are variable definitions files given by --var-file.
+	VarFiles []string
+	// InitState decides how .terraform/terraform.tfstate is used. One of fallback, prefer, and ignore.
+	InitState string
+}
+
+// Unused outputs data sources which aren't referred in their directory and outputs of producers which no consumer refers.
+func Unused(ctx context.Context, logger *slog.Logger, afs afero.Fs, param *Param) error {
+	if param.Format != "text" && param.Format != "json" {
+		return slogerr.With(errors.New("unsupported format"), "output_format", param.Format) //nolint:wrapcheck
+	}
+	vars, err := tfrstate.ParseVars(param.Vars)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	result, err := tfrstate.FindUnused(ctx, logger, afs, &tfrstate.UnusedOptions{
+		WorkDir:   param.PWD,
+		BaseDir:   param.Root,
+		Vars:      vars,
+		VarFiles:  param.VarFiles,
+		InitState: param.InitState,
+	})
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	if err := tfrstate.WriteDiagnostics(param.Stderr, afs, result.Diagnostics); err != nil {
+		return err //nolint:wrapcheck
+	}
+	if len(result.Unresolved) > 0 {
+		logger.Warn("some consumers can't be resolved, so outputs referred by them are treated as used in all producers", "num_of_unresolved", len(result.Unresolved))
+	}
+	if param.Format == "json" {
+		encoder := json.NewEncoder(param.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result); err != nil {
+			return fmt.Errorf("encode the result as JSON: %w", err)
+		}
+		return nil
+	}
+	return outputText(param.Stdout, result)
+}
+
+// outputText outputs unused data sources and outputs line by line.
+//
+//	foo/main.tf:1: data.terraform_remote_state.network is never referenced
+//	network/outputs.tf:5: output vpc_id is never referenced
+func outputText(w io.Writer, result *tfrstate.UnusedResult) error {
+	for _, ds := range result.DataSources {
+		if _, err := fmt.Fprintf(w, "%s:%d: data.%s.%s is never referenced\n", filepath.ToSlash(filepath.Join(ds.Dir, ds.File)), ds.Range.Line, ds.Kind, ds.Name); err != nil {
+			return fmt.Errorf("output an unused data source: %w", err)
+		}
+	}
+	for _, output := range result.Outputs {
+		if _, err := fmt.Fprintf(w, "%s:%d: output %s is never referenced\n", filepath.ToSlash(filepath.Join(output.Dir, output.File)), output.Range.Line, output.Name); err != nil {
+			return fmt.Errorf("output an unused output: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+// Package schema provides JSON Schemas of outputs of tfrstate.
+package schema
+
+import (
+	_ "embed"
+)
+
+// OutputV2 is the JSON Schema of the JSON output with --output-version 2.
+//
+//go:embed output-v2.json
+var OutputV2 []byte //nolint:gochecknoglobals
@@ -0,0 +1,397 @@
+package tfrstate
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// findBackendConfig finds the backend configuration of the Terraform Root Module in dir.
+// dir must be an absolute path.
+// initStateMode decides how .terraform/terraform.tfstate is used. The empty string is treated as InitStateFallback.
+// If no backend is found or the backend type isn't supported, it returns nil.
+// Files which can't be parsed and unsupported backend types are returned as diagnostics.
+func findBackendConfig(logger *slog.Logger, afs afero.Fs, registry *Registry, dir string, cliVars map[string]cty.Value, backendConfigs []string, initStateMode string) (*Bucket, hcl.Diagnostics, error) {
+	decl, diags, err := findBackend(logger, afs, dir, cliVars, backendConfigs, initStateMode)
+	if err != nil || decl == nil {
+		return nil, diags, err
+	}
+	bucket, ds, err := newBackendBucket(registry, decl.Type, decl.Config, dir)
+	return bucket, diags.Extend(ds), err
+}
+
+// findBackend finds the backend type and the configuration of the Terraform Root Module in dir.
+// The configuration is merged with partial backend configurations and .terraform/terraform.tfstate.
+// If no backend is found, it returns nil.
+func findBackend(logger *slog.Logger, afs afero.Fs, dir string, cliVars map[string]cty.Value, backendConfigs []string, initStateMode string) (*backendDecl, hcl.Diagnostics, error) {
+	var initDecl *backendDecl
+	var diags hcl.Diagnostics
+	if initStateMode != InitStateIgnore {
+		t, c, err := readInitState(afs, dir)
+		switch {
+		case err != nil && initStateMode == InitStatePrefer:
+			return nil, nil, err
+		case err != nil:
+			// In the fallback mode, the backend block can be used without .terraform/terraform.tfstate
+			diags = diags.Append(newDiagnostic(hcl.DiagWarning, DiagnosticCodeInvalidBackend,
+				"Invalid .terraform/terraform.tfstate",
+				"The backend configuration recorded by terraform init is ignored because it can't be read: "+err.Error(),
+				&hcl.Range{Filename: initStatePath(dir)}))
+		case t != "":
+			initDecl = &backendDecl{Type: t, Config: c}
+		}
+	}
+	if initDecl != nil && initStateMode == InitStatePrefer {
+		logger.Debug("use the backend configuration of .terraform/terraform.tfstate", "backend_type", initDecl.Type)
+		return initDecl, nil, nil
+	}
+	evalCtx, err := newEvalContext(logger, afs, dir, cliVars)
+	if err != nil {
+		return nil, diags, err
+	}
+	// parse HCLs in dir and extract backend configurations
+	decl, ds, err := findBackendBlock(logger, afs, dir, evalCtx)
+	diags = diags.Extend(ds)
+	if err != nil {
+		return nil, diags, err
+	}
+	if decl == nil {
+		if initDecl != nil {
+			logger.Debug("the backend block isn't found, so the backend configuration of .terraform/terraform.tfstate is used", "backend_type", initDecl.Type)
+		}
+		return initDecl, diags, nil
+	}
+	// merge partial backend configurations over the backend block like terraform init -backend-config
+	partial, err := readBackendConfigs(logger, afs, dir, backendConfigs)
+	if err != nil {
+		return nil, diags, err
+	}
+	maps.Copy(decl.Config, partial)
+	if initDecl != nil && decl.Type == initDecl.Type {
+		// terraform init records the backend block merged with -backend-config,
+		// so it complements attributes which are partial or can't be resolved statically.
+		fillConfig(decl.Config, initDecl.Config)
+	}
+	return decl, diags, nil
+}
+
+// newBackendBucket returns the normalized backend.
+// If the backend type isn't supported, it returns nil and a warning diagnostic.
+func newBackendBucket(registry *Registry, backendType string, config map[string]cty.Value, dir string) (*Bucket, hcl.Diagnostics, error) {
+	if _, ok := registry.Get(backendType); !ok {
+		return nil, hcl.Diagnostics{newDiagnostic(hcl.DiagWarning, DiagnosticCodeInvalidBackend,
+			"Unsupported backend type",
+			fmt.Sprintf("The backend type %q of %s isn't supported, so consumers of the Terraform State can't be found.", backendType, dir), nil)}, nil
+	}
+	bucket, err := registry.Bucket(backendType, config, dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get the backend configuration: %w", slogerr.With(err, "backend_type", backendType))
+	}
+	return bucket, nil, nil
+}
+
+// fillConfig sets attributes of src to dest if they aren't set or can't be resolved statically in dest.
+func fillConfig(dest, src map[string]cty.Value) {
+	for key, val := range src {
+		if val.IsNull() {
+			continue
+		}
+		if cur, ok := dest[key]; ok && !cur.IsNull() && cur.IsWhollyKnown() {
+			continue
+		}
+		dest[key] = val
+	}
+}
+
+// backendDecl is a backend block or a cloud block declared in a terraform block.
+// It's also used for backends of .terraform/terraform.tfstate and Terragrunt remote_state blocks.
+type backendDecl struct {
+	// Type is the backend type. The cloud block is treated as the remote backend.
+	Type   string
+	Config map[string]cty.Value
+	// Range is the range of the backend block. It's empty if the backend isn't declared by a backend block.
+	Range hcl.Range
+}
+
+// isOverrideFile returns true if the file is a Terraform override file such as override.tf and foo_override.tf.json.
+// https://developer.hashicorp.com/terraform/language/files/override
+func isOverrideFile(path string) bool {
+	name := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(path), ".json"), ".tf")
+	return name == "override" || strings.HasSuffix(name, "_override")
+}
+
+// findBackendBlock finds a backend block in *.tf and *.tf.json.
+// Like Terraform, backends in override files replace the backend in primary files entirely,
+// and override files are applied in lexical order.
+// If no backend block is found, the remote_state block of Terragrunt is used.
+// If neither is found, it returns nil.
+// Files which can't be parsed are skipped and returned as diagnostics.
+// Duplicate backends in primary files are returned as diagnostics, and the first one is used.
+func findBackendBlock(logger *slog.Logger, afs afero.Fs, dir string, evalCtx *hcl.EvalContext) (*backendDecl, hcl.Diagnostics, error) {
+	var matchFiles []string
+	for _, pattern := range []string{"*.tf", "*.tf.json"} {
+		files, err := afero.Glob(afs, filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, nil, fmt.Errorf("glob %s to get Backend configuration: %w", pattern, err)
+		}
+		matchFiles = append(matchFiles, files...)
+	}
+	slices.Sort(matchFiles)
+	primaries := make([]string, 0, len(matchFiles))
+	var overrides []string
+	for _, matchFile := range matchFiles {
+		if isOverrideFile(matchFile) {
+			overrides = append(overrides, matchFile)
+			continue
+		}
+		primaries = append(primaries, matchFile)
+	}
+
+	var diags hcl.Diagnostics
+	var decl *backendDecl
+	for _, matchFile := range primaries {
+		decls, ds, err := readBackendDecls(logger, afs, matchFile, evalCtx)
+		diags = diags.Extend(ds)
+		if err != nil {
+			return nil, diags, err
+		}
+		for _, d := range decls {
+			if decl != nil {
+				diags = diags.Append(newDiagnostic(hcl.DiagError, DiagnosticCodeDuplicateBackend,
+					"Duplicate backend configuration",
+					fmt.Sprintf("A module may have only one backend configuration. The backend was previously configured at %s line %d.", filepath.Base(decl.Range.Filename), decl.Range.Start.Line),
+					d.Range.Ptr()))
+				continue
+			}
+			decl = d
+		}
+	}
+	for _, matchFile := range overrides {
+		decls, ds, err := readBackendDecls(logger, afs, matchFile, evalCtx)
+		diags = diags.Extend(ds)
+		if err != nil {
+			return nil, diags, err
+		}
+		for _, d := range decls {
+			logger.Debug("the backend is overridden", "file", matchFile, "backend_type", d.Type)
+			decl = d
+		}
+	}
+	if decl != nil {
+		return decl, diags, nil
+	}
+	backendType, config, ds, err := findTerragruntBackend(logger, afs, dir)
+	diags = diags.Extend(ds)
+	if err != nil {
+		return nil, diags, fmt.Errorf("get backend configuration from terragrunt.hcl: %w", err)
+	}
+	if backendType == "" {
+		return nil, diags, nil
+	}
+	return &backendDecl{Type: backendType, Config: config}, diags, nil
+}
+
+// readBackendDecls returns backends declared in a *.tf or *.tf.json file.
+// If the file can't be parsed, it returns diagnostics.
+func readBackendDecls(logger *slog.Logger, afs afero.Fs, path string, evalCtx *hcl.EvalContext) ([]*backendDecl, hcl.Diagnostics, error) {
+	b, err := afero.ReadFile(afs, path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read a file: %w", slogerr.With(err, "file", path))
+	}
+	s := string(b)
+	if !strings.Contains(s, "backend") && !strings.Contains(s, "cloud") {
+		return nil, nil, nil
+	}
+	if strings.HasSuffix(path, ".json") {
+		decls, diags := extractBackendsFromJSON(logger, b, path, evalCtx)
+		return decls, diags, nil
+	}
+	decls, err := extractBackends(logger, b, path, evalCtx)
+	if err != nil {
+		return nil, toDiagnostics(err, DiagnosticCodeParseError, path), nil
+	}
+	return decls, nil, nil
+}
+
+func extractBackends(logger *slog.Logger, src []byte, filePath string, evalCtx *hcl.EvalContext) ([]*backendDecl, error) {
+	body, err := parseHCLBody(src, filePath)
+	if err != nil {
+		return nil, err
+	}
+	var decls []*backendDecl
+	for _, block := range body.Blocks {
+		decls = append(decls, handleTerraformBlock(logger, block, evalCtx)...)
+	}
+	return decls, nil
+}
+
+// handleTerraformBlock returns backend blocks and cloud blocks in a terraform block.
+func handleTerraformBlock(logger *slog.Logger, block *hclsyntax.Block, evalCtx *hcl.EvalContext) []*backendDecl {
+	/*
+		terraform {
+		  backend "s3" {
+		    bucket = "terraform-state-prod"
+		    key    = "network/terraform.tfstate"
+		  }
+		}
+		terraform {
+		  backend "gcs" {
+		    bucket  = "tf-state-prod"
+		    prefix  = "terraform/state"
+		  }
+		}
+		terraform {
+		  cloud {
+		    organization = "my-org"
+		    workspaces {
+		      name = "network"
+		    }
+		  }
+		}
+	*/
+	if block.Type != "terraform" {
+		return nil
+	}
+	var decls []*backendDecl
+	for _, backend := range block.Body.Blocks {
+		switch {
+		case backend.Type == "cloud":
+			// The cloud block stores states in the same way as the remote backend
+			decls = append(decls, &backendDecl{
+				Type:   BackendTypeRemote,
+				Config: evalBackendBlock(logger, backend, evalCtx),
+				Range:  backend.DefRange(),
+			})
+		case backend.Type == "backend" && len(backend.Labels) == 1:
+			decls = append(decls, &backendDecl{
+				Type:   backend.Labels[0],
+				Config: evalBackendBlock(logger, backend, evalCtx),
+				Range:  backend.DefRange(),
+			})
+		}
+	}
+	return decls
+}
+
+// evalBackendBlock evaluates attributes of a backend block.
+// Nested blocks such as workspaces are converted to objects.
+// Attributes which can't be resolved are unknown.
+func evalBackendBlock(logger *slog.Logger, backend *hclsyntax.Block, evalCtx *hcl.EvalContext) map[string]cty.Value {
+	config := make(map[string]cty.Value, len(backend.Body.Attributes)+len(backend.Body.Blocks))
+	for _, block := range backend.Body.Blocks {
+		config[block.Type] = cty.ObjectVal(evalBackendBlock(logger, block, evalCtx))
+	}
+	for name, attr := range backend.Body.Attributes {
+		val, err := evalValue(attr.Expr, evalCtx)
+		if err != nil {
+			slogerr.WithError(logger, err).Debug("evaluate an attribute of the backend block", "attr", name)
+			config[name] = cty.DynamicVal
+			continue
+		}
+		config[name] = val
+	}
+	return config
+}
+
+// readBackendConfigs reads partial backend configurations given by --backend-config.
+// Each configuration is either a file path or a pair of key and value separated by "=".
+// If no configuration is given, a *.tfbackend file in dir is used if it's the only *.tfbackend file.
+//
+//	--backend-config backend.hcl
+//	--backend-config prod.tfbackend
+//	--backend-config key=network/terraform.tfstate
+func readBackendConfigs(logger *slog.Logger, afs afero.Fs, dir string, backendConfigs []string) (map[string]cty.Value, error) {
+	config := map[string]cty.Value{}
+	if len(backendConfigs) == 0 {
+		files, err := afero.Glob(afs, filepath.Join(dir, "*.tfbackend"))
+		if err != nil {
+			return nil, fmt.Errorf("glob *.tfbackend: %w", err)
+		}
+		switch len(files) {
+		case 0:
+			return config, nil
+		case 1:
+			logger.Debug("use a backend configuration file", "backend_config", files[0])
+			backendConfigs = files
+		default:
+			logger.Warn("multiple *.tfbackend files are found. Please specify a file by --backend-config", "backend_configs", files)
+			return config, nil
+		}
+	}
+	for _, backendConfig := range backendConfigs {
+		if key, value, ok := strings.Cut(backendConfig, "="); ok {
+			config[key] = cty.StringVal(value)
+			continue
+		}
+		if err := readAttributesFile(afs, backendConfig, config); err != nil {
+			return nil, fmt.Errorf("read a backend configuration file: %w", slogerr.With(err, "backend_config", backendConfig))
+		}
+	}
+	return config, nil
+}
+
+// configString returns a string attribute of a backend configuration.
+// Nested attributes are separated by "." such as "endpoints.s3".
+// If the attribute isn't set, it returns an empty string.
+func configString(config map[string]cty.Value, name string) (string, error) {
+	val, ok, err := lookupConfig(config, name)
+	if err != nil {
+		return "", err
+	}
+	if !ok || val.IsNull() {
+		return "", nil
+	}
+	if !val.IsWhollyKnown() {
+		return "", slogerr.With(errors.New("the attribute of the backend configuration can't be resolved statically"), "attr", name) //nolint:wrapcheck
+	}
+	s, err := convert.Convert(val, cty.String)
+	if err != nil {
+		return "", fmt.Errorf("the attribute of the backend configuration must be a string: %w", slogerr.With(err, "attr", name))
+	}
+	return s.AsString(), nil
+}
+
+// lookupConfig returns an attribute of a backend configuration.
+// Nested attributes are separated by ".".
+func lookupConfig(config map[string]cty.Value, name string) (cty.Value, bool, error) {
+	first, rest, nested := strings.Cut(name, ".")
+	val, ok := config[first]
+	for ok && nested {
+		if val.IsNull() {
+			return val, true, nil
+		}
+		if !val.IsKnown() {
+			return cty.DynamicVal, true, nil
+		}
+		var attr string
+		attr, rest, nested = strings.Cut(rest, ".")
+		ty := val.Type()
+		switch {
+		case ty.IsObjectType():
+			ok = ty.HasAttribute(attr)
+			if ok {
+				val = val.GetAttr(attr)
+			}
+		case ty.IsMapType():
+			key := cty.StringVal(attr)
+			ok = val.HasIndex(key).True()
+			if ok {
+				val = val.Index(key)
+			}
+		default:
+			return cty.NilVal, false, slogerr.With(errors.New("the attribute of the backend configuration must be an object"), "attr", name) //nolint:wrapcheck
+		}
+	}
+	return val, ok, nil
+}
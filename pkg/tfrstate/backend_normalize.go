@@ -0,0 +1,54 @@
+package tfrstate
+
+import (
+	"strings"
+)
+
+// normalizeBucketName removes the URL scheme such as "s3://" and slashes from a bucket name and converts it to lower case.
+// Bucket names of S3, GCS, OSS, and COS are case-insensitive in practice because upper case letters aren't allowed.
+//
+//	s3://MyBucket/ => mybucket
+func normalizeBucketName(bucket, scheme string) string {
+	bucket = strings.TrimPrefix(strings.ToLower(bucket), scheme)
+	return strings.Trim(bucket, "/")
+}
+
+// normalizeObjectPath removes leading, trailing, and duplicate slashes from an object key or prefix.
+//
+//	/network//terraform.tfstate => network/terraform.tfstate
+func normalizeObjectPath(p string) string {
+	elems := strings.Split(p, "/")
+	paths := make([]string, 0, len(elems))
+	for _, elem := range elems {
+		if elem != "" {
+			paths = append(paths, elem)
+		}
+	}
+	return strings.Join(paths, "/")
+}
+
+// normalizeGCS normalizes the identity of the gcs backend.
+// The state of the default workspace is stored as <prefix>/default.tfstate,
+// so the suffix default.tfstate is removed if the prefix is given as the object path.
+func normalizeGCS(identity map[string]string, _ string) {
+	identity["bucket"] = normalizeBucketName(identity["bucket"], "gs://")
+	prefix := normalizeObjectPath(identity["prefix"])
+	if prefix == "default.tfstate" {
+		prefix = ""
+	}
+	identity["prefix"] = strings.TrimSuffix(prefix, "/default.tfstate")
+}
+
+// normalizeObjectStorage normalizes the identity of backends storing states as <prefix>/<key> in a bucket such as oss and cos.
+func normalizeObjectStorage(scheme string) func(identity map[string]string, dir string) {
+	return func(identity map[string]string, _ string) {
+		identity["bucket"] = normalizeBucketName(identity["bucket"], scheme)
+		identity["prefix"] = normalizeObjectPath(identity["prefix"])
+		identity["key"] = normalizeObjectPath(identity["key"])
+	}
+}
+
+// normalizeConsul normalizes the path of the consul backend.
+func normalizeConsul(identity map[string]string, _ string) {
+	identity["path"] = normalizeObjectPath(identity["path"])
+}
@@ -0,0 +1,423 @@
+package tfrstate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	hcljson "github.com/hashicorp/hcl/v2/json"
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// Built-in backend types.
+const (
+	BackendTypeGCS        = "gcs"
+	BackendTypeS3         = "s3"
+	BackendTypeLocal      = "local"
+	BackendTypeHTTP       = "http"
+	BackendTypeConsul     = "consul"
+	BackendTypePG         = "pg"
+	BackendTypeKubernetes = "kubernetes"
+	BackendTypeOSS        = "oss"
+	BackendTypeCOS        = "cos"
+	BackendTypeRemote     = "remote"
+)
+
+// Bucket identifies a Terraform State.
+// Identity has configuration fields identifying the state, such as bucket and key of the S3 backend.
+// Fields of each backend type are defined by BackendType.
+type Bucket struct {
+	Type     string            `json:"type"`
+	Identity map[string]string `json:"identity"`
+}
+
+func (b *Bucket) LogAttrs() []any {
+	attrs := make([]any, 0, 2+2*len(b.Identity)) //nolint:mnd
+	attrs = append(attrs, "type", b.Type)
+	for _, k := range slices.Sorted(maps.Keys(b.Identity)) {
+		if v := b.Identity[k]; v != "" {
+			attrs = append(attrs, k, v)
+		}
+	}
+	return attrs
+}
+
+// String returns a human readable representation of the backend configuration.
+//
+//	type=s3 bucket=mybucket key=path/to/my/key
+func (b *Bucket) String() string {
+	attrs := b.LogAttrs()
+	pairs := make([]string, 0, len(attrs)/2) //nolint:mnd
+	for i := 0; i+1 < len(attrs); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", attrs[i], attrs[i+1]))
+	}
+	return strings.Join(pairs, " ")
+}
+
+func (b *Bucket) Compare(bucket *Bucket) bool {
+	return b.Type == bucket.Type && maps.Equal(b.Identity, bucket.Identity)
+}
+
+func (b *Bucket) Copy(bucket *Bucket) {
+	bucket.Type = b.Type
+	bucket.Identity = maps.Clone(b.Identity)
+}
+
+// BackendType defines how a Terraform State of a backend type is identified.
+type BackendType struct {
+	// Name is the backend type such as "s3".
+	Name string
+	// IdentityFields are configuration fields identifying a Terraform State.
+	// Nested fields are separated by "." such as "endpoints.s3".
+	IdentityFields []string
+	// OptionalFields are identity fields which are treated as unset if they can't be resolved statically.
+	OptionalFields []string
+	// Defaults are default values of identity fields.
+	Defaults map[string]string
+	// Normalize normalizes identity fields so that equivalent configurations are equal.
+	// dir is the absolute path of the Terraform Module where the backend or terraform_remote_state is defined.
+	// Normalize is optional.
+	Normalize func(identity map[string]string, dir string)
+	// Attributes are all top level attributes of the backend configuration.
+	// They are used to find unknown attributes in the config of terraform_remote_state.
+	// If Attributes is empty, unknown attributes aren't checked.
+	Attributes []string
+	// LintFields are fields which consumers should set to the same value as the backend of the producer.
+	// Nested fields are separated by "." such as "assume_role.role_arn".
+	LintFields []string
+	// Aliases maps deprecated fields to their replacements such as "role_arn" to "assume_role.role_arn".
+	// They are used to compare configurations using deprecated fields with ones using replacements.
+	Aliases map[string]string
+}
+
+// Registry is a set of supported backend types.
+type Registry struct {
+	types map[string]*BackendType
+}
+
+// NewRegistry returns a registry with built-in backend types.
+func NewRegistry() *Registry {
+	r := &Registry{
+		types: map[string]*BackendType{},
+	}
+	for _, bt := range builtinBackendTypes() {
+		r.Register(bt)
+	}
+	return r
+}
+
+// Register adds a backend type to the registry.
+// If the backend type is already registered, it's overwritten.
+func (r *Registry) Register(bt *BackendType) {
+	r.types[bt.Name] = bt
+}
+
+// Get returns a registered backend type.
+func (r *Registry) Get(name string) (*BackendType, bool) {
+	bt, ok := r.types[name]
+	return bt, ok
+}
+
+// Bucket builds a normalized Bucket from the configuration of a backend block or a terraform_remote_state data source.
+// dir is the absolute path of the Terraform Module where the configuration is defined.
+func (r *Registry) Bucket(backendType string, config map[string]cty.Value, dir string) (*Bucket, error) {
+	bt, ok := r.Get(backendType)
+	if !ok {
+		return nil, slogerr.With(errors.New("unsupported backend type"), "backend_type", backendType) //nolint:wrapcheck
+	}
+	identity := make(map[string]string, len(bt.IdentityFields))
+	for _, field := range bt.IdentityFields {
+		v, err := configString(config, field)
+		if err != nil {
+			if !slices.Contains(bt.OptionalFields, field) {
+				return nil, err
+			}
+			v = ""
+		}
+		if v == "" {
+			v = bt.Defaults[field]
+		}
+		identity[field] = v
+	}
+	if bt.Normalize != nil {
+		bt.Normalize(identity, dir)
+	}
+	return &Bucket{
+		Type:     backendType,
+		Identity: identity,
+	}, nil
+}
+
+func builtinBackendTypes() []*BackendType {
+	return []*BackendType{
+		{
+			// https://developer.hashicorp.com/terraform/language/backend/s3
+			Name:           BackendTypeS3,
+			IdentityFields: []string{"bucket", "key", "endpoints.s3", "endpoint", "region"},
+			OptionalFields: []string{"region"},
+			Normalize:      normalizeS3,
+			Attributes: []string{
+				"bucket", "key", "region", "encrypt", "kms_key_id", "sse_customer_key", "acl",
+				"workspace_key_prefix", "dynamodb_table", "use_lockfile",
+				"access_key", "secret_key", "token", "profile",
+				"shared_config_files", "shared_credentials_files", "shared_credentials_file",
+				"assume_role", "assume_role_with_web_identity",
+				"role_arn", "session_name", "external_id", "assume_role_duration_seconds",
+				"assume_role_policy", "assume_role_policy_arns", "assume_role_tags", "assume_role_transitive_tag_keys",
+				"endpoints", "endpoint", "dynamodb_endpoint", "iam_endpoint", "sts_endpoint", "sts_region",
+				"custom_ca_bundle", "ec2_metadata_service_endpoint", "ec2_metadata_service_endpoint_mode",
+				"http_proxy", "https_proxy", "no_proxy", "insecure",
+				"use_dualstack_endpoint", "use_fips_endpoint", "use_path_style", "force_path_style",
+				"allowed_account_ids", "forbidden_account_ids", "max_retries", "retry_mode",
+				"skip_credentials_validation", "skip_metadata_api_check", "skip_region_validation",
+				"skip_requesting_account_id", "skip_s3_checksum",
+			},
+			LintFields: []string{"region", "encrypt", "assume_role.role_arn", "workspace_key_prefix", "endpoints.s3"},
+			Aliases: map[string]string{
+				"role_arn": "assume_role.role_arn",
+				"endpoint": "endpoints.s3",
+			},
+		},
+		{
+			// https://developer.hashicorp.com/terraform/language/backend/gcs
+			Name:           BackendTypeGCS,
+			IdentityFields: []string{"bucket", "prefix"},
+			Normalize:      normalizeGCS,
+			Attributes: []string{
+				"bucket", "prefix", "credentials", "access_token",
+				"impersonate_service_account", "impersonate_service_account_delegates",
+				"encryption_key", "kms_encryption_key", "storage_custom_endpoint",
+			},
+		},
+		{
+			// https://developer.hashicorp.com/terraform/language/backend/local
+			Name:           BackendTypeLocal,
+			IdentityFields: []string{"path"},
+			Defaults: map[string]string{
+				"path": "terraform.tfstate",
+			},
+			Normalize:  normalizeLocal,
+			Attributes: []string{"path", "workspace_dir"},
+		},
+		{
+			// https://developer.hashicorp.com/terraform/language/backend/http
+			Name:           BackendTypeHTTP,
+			IdentityFields: []string{"address"},
+			Attributes: []string{
+				"address", "update_method", "lock_address", "lock_method", "unlock_address", "unlock_method",
+				"username", "password", "skip_cert_verification", "retry_max", "retry_wait_min", "retry_wait_max",
+				"client_ca_certificate_pem", "client_certificate_pem", "client_private_key_pem",
+			},
+		},
+		{
+			// https://developer.hashicorp.com/terraform/language/backend/consul
+			Name:           BackendTypeConsul,
+			IdentityFields: []string{"path"},
+			Normalize:      normalizeConsul,
+			Attributes: []string{
+				"path", "access_token", "address", "scheme", "datacenter", "http_auth", "gzip", "lock",
+				"ca_file", "cert_file", "key_file",
+			},
+			LintFields: []string{"address", "datacenter"},
+		},
+		{
+			// https://developer.hashicorp.com/terraform/language/backend/pg
+			Name:           BackendTypePG,
+			IdentityFields: []string{"conn_str", "schema_name"},
+			Defaults: map[string]string{
+				"schema_name": "terraform_remote_state",
+			},
+			Attributes: []string{"conn_str", "schema_name", "skip_schema_creation", "skip_table_creation", "skip_index_creation"},
+		},
+		{
+			// https://developer.hashicorp.com/terraform/language/backend/kubernetes
+			Name:           BackendTypeKubernetes,
+			IdentityFields: []string{"secret_suffix", "namespace"},
+			Defaults: map[string]string{
+				"namespace": "default",
+			},
+			Attributes: []string{
+				"secret_suffix", "labels", "namespace", "in_cluster_config", "host", "username", "password", "insecure",
+				"client_certificate", "client_key", "cluster_ca_certificate", "config_path", "config_paths",
+				"config_context", "config_context_auth_info", "config_context_cluster", "token", "exec",
+			},
+			LintFields: []string{"host", "config_context"},
+		},
+		{
+			// https://developer.hashicorp.com/terraform/language/backend/oss
+			Name:           BackendTypeOSS,
+			IdentityFields: []string{"bucket", "prefix", "key"},
+			Defaults: map[string]string{
+				"prefix": "env:",
+				"key":    "terraform.tfstate",
+			},
+			Normalize: normalizeObjectStorage("oss://"),
+		},
+		{
+			// https://developer.hashicorp.com/terraform/language/backend/cos
+			Name:           BackendTypeCOS,
+			IdentityFields: []string{"bucket", "prefix", "key"},
+			Defaults: map[string]string{
+				"prefix": "env:",
+				"key":    "terraform.tfstate",
+			},
+			Normalize: normalizeObjectStorage("cos://"),
+		},
+		{
+			// https://developer.hashicorp.com/terraform/language/backend/remote
+			// The cloud block is also treated as the remote backend.
+			// hostname isn't included because tfe_outputs doesn't have it.
+			Name:           BackendTypeRemote,
+			IdentityFields: []string{"organization", "workspaces.name"},
+			Attributes:     []string{"hostname", "organization", "token", "workspaces"},
+			LintFields:     []string{"hostname"},
+		},
+	}
+}
+
+// normalizeS3 normalizes the bucket and the key, and identifies the S3 service where the bucket is located.
+// Bucket names are unique only in an AWS partition or an S3-compatible storage such as MinIO,
+// so the identity has the custom endpoint or the AWS partition derived from the region.
+// The deprecated attribute endpoint is used if endpoints.s3 isn't set.
+func normalizeS3(identity map[string]string, _ string) {
+	identity["bucket"] = normalizeBucketName(identity["bucket"], "s3://")
+	identity["key"] = normalizeObjectPath(identity["key"])
+	endpoint := identity["endpoints.s3"]
+	if endpoint == "" {
+		endpoint = identity["endpoint"]
+	}
+	delete(identity, "endpoints.s3")
+	region := identity["region"]
+	delete(identity, "region")
+	if endpoint != "" {
+		identity["endpoint"] = normalizeEndpoint(endpoint)
+		return
+	}
+	delete(identity, "endpoint")
+	identity["partition"] = awsPartition(region)
+}
+
+// normalizeEndpoint removes the scheme and the trailing slash from an endpoint URL and converts it to lower case.
+func normalizeEndpoint(endpoint string) string {
+	endpoint = strings.ToLower(endpoint)
+	if _, after, ok := strings.Cut(endpoint, "://"); ok {
+		endpoint = after
+	}
+	return strings.TrimRight(endpoint, "/")
+}
+
+// awsPartition returns the AWS partition of a region.
+// If the region is empty, the standard partition "aws" is returned.
+func awsPartition(region string) string {
+	for _, p := range []struct {
+		prefix    string
+		partition string
+	}{
+		{"cn-", "aws-cn"},
+		{"us-gov-", "aws-us-gov"},
+		{"us-isob-", "aws-iso-b"},
+		{"us-iso-", "aws-iso"},
+		{"eu-isoe-", "aws-iso-e"},
+		{"us-isof-", "aws-iso-f"},
+	} {
+		if strings.HasPrefix(region, p.prefix) {
+			return p.partition
+		}
+	}
+	return "aws"
+}
+
+// normalizeLocal converts the path of the local backend to an absolute path because it's a relative path from dir.
+func normalizeLocal(identity map[string]string, dir string) {
+	p := identity["path"]
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(dir, p)
+	}
+	identity["path"] = filepath.Clean(p)
+}
+
+// extractBackendsFromJSON returns backends declared in a *.tf.json file.
+// Attributes which can't be resolved are unknown.
+//
+//	{
+//	  "terraform": {
+//	    "backend": {
+//	      "s3": {
+//	        "bucket": "terraform-state-prod",
+//	        "key": "network/terraform.tfstate"
+//	      }
+//	    }
+//	  }
+//	}
+func extractBackendsFromJSON(logger *slog.Logger, src []byte, filePath string, evalCtx *hcl.EvalContext) ([]*backendDecl, hcl.Diagnostics) {
+	file, diags := hcljson.Parse(src, filePath)
+	if diags.HasErrors() {
+		return nil, toDiagnostics(diags, DiagnosticCodeParseError, filePath)
+	}
+	content, _, diags := file.Body.PartialContent(&hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{{Type: "terraform"}},
+	})
+	if diags.HasErrors() {
+		return nil, toDiagnostics(diags, DiagnosticCodeParseError, filePath)
+	}
+	var decls []*backendDecl
+	for _, tf := range content.Blocks {
+		backends, _, diags := tf.Body.PartialContent(&hcl.BodySchema{
+			Blocks: []hcl.BlockHeaderSchema{
+				{Type: "backend", LabelNames: []string{"type"}},
+				{Type: "cloud"},
+			},
+		})
+		if diags.HasErrors() {
+			return nil, toDiagnostics(diags, DiagnosticCodeParseError, filePath)
+		}
+		for _, block := range backends.Blocks {
+			backendType := BackendTypeRemote
+			if block.Type == "backend" {
+				backendType = block.Labels[0]
+			}
+			attrs, diags := block.Body.JustAttributes()
+			if diags.HasErrors() {
+				return nil, toDiagnostics(diags, DiagnosticCodeParseError, filePath)
+			}
+			config := make(map[string]cty.Value, len(attrs))
+			for name, attr := range attrs {
+				val, diags := attr.Expr.Value(evalCtx)
+				if diags.HasErrors() {
+					slogerr.WithError(logger, diags).Debug("evaluate an attribute of the backend block", "attr", name)
+					val = cty.DynamicVal
+				}
+				config[name] = val
+			}
+			decls = append(decls, &backendDecl{
+				Type:   backendType,
+				Config: config,
+				Range:  block.DefRange,
+			})
+		}
+	}
+	return decls, nil
+}
+
+func unmarshalBackendJSON(raw json.RawMessage) (map[string]cty.Value, error) {
+	ty, err := ctyjson.ImpliedType(raw)
+	if err != nil {
+		return nil, fmt.Errorf("get the type of the backend configuration: %w", err)
+	}
+	val, err := ctyjson.Unmarshal(raw, ty)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal the backend configuration: %w", err)
+	}
+	if !val.Type().IsObjectType() {
+		return nil, errors.New("the backend configuration must be an object")
+	}
+	return val.AsValueMap(), nil
+}
@@ -0,0 +1,326 @@
+package tfrstate
+
+import (
+	"cmp"
+	"errors"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Reference is a reference to an output of a consumer such as terraform_remote_state data source, tfe_outputs data source, and Terragrunt dependency.
+//
+//	data.terraform_remote_state.<data source name>.outputs.<output name>
+//	data.tfe_outputs.<data source name>.values.<output name>
+//	dependency.<dependency name>.outputs.<output name>
+type Reference struct {
+	Address string `json:"address"`
+	// Kind is the kind of the consumer such as "terraform_remote_state", "tfe_outputs", and "dependency".
+	Kind       string `json:"kind"`
+	DataSource string `json:"data_source"`
+	// InstanceKey is an instance key such as `"api"` and `0` if the data source has for_each or count.
+	// If the instance key is computed dynamically such as `each.key`, it's the expression of the key.
+	InstanceKey string `json:"instance_key,omitempty"`
+	Output      string `json:"output"`
+	// dynamic is true if the instance key is computed dynamically.
+	dynamic bool
+	// Change is the kind of the output change such as "updated", "removed", and "renamed".
+	// It's empty if it's unknown.
+	Change string `json:"change,omitempty"`
+	// RenamedTo is the new output name if the output is renamed.
+	RenamedTo string `json:"renamed_to,omitempty"`
+	// Suggestion is the reference to the new output if the output is renamed.
+	Suggestion string `json:"suggestion,omitempty"`
+	Range      *Range `json:"range"`
+}
+
+// Breaking returns true if the referred output doesn't exist after the change, that is, the output is removed or renamed.
+func (r *Reference) Breaking() bool {
+	return r.Change == ChangeKindRemoved || r.Change == ChangeKindRenamed
+}
+
+// Range is a source range of a reference in a file.
+type Range struct {
+	Line      int `json:"line"`
+	Column    int `json:"column"`
+	EndLine   int `json:"end_line"`
+	EndColumn int `json:"end_column"`
+}
+
+func newRange(rng hcl.Range) *Range {
+	return &Range{
+		Line:      rng.Start.Line,
+		Column:    rng.Start.Column,
+		EndLine:   rng.End.Line,
+		EndColumn: rng.End.Column,
+	}
+}
+
+// consumerKind is a kind of consumers which refer outputs of a Terraform State.
+type consumerKind struct {
+	// Name is the name of the kind such as "terraform_remote_state".
+	Name string
+	// Prefix is the traversal before the consumer name.
+	Prefix []string
+	// Outputs are attributes having outputs of the Terraform State.
+	Outputs []string
+}
+
+const (
+	consumerKindRemoteState = "terraform_remote_state"
+	consumerKindTFEOutputs  = "tfe_outputs"
+	consumerKindDependency  = "dependency"
+)
+
+func consumerKinds() []*consumerKind {
+	return []*consumerKind{
+		{
+			//	data.terraform_remote_state.<name>.outputs.<output>
+			Name:    consumerKindRemoteState,
+			Prefix:  []string{"data", "terraform_remote_state"},
+			Outputs: []string{"outputs"},
+		},
+		{
+			//	data.tfe_outputs.<name>.values.<output>
+			//	data.tfe_outputs.<name>.nonsensitive_values.<output>
+			Name:    consumerKindTFEOutputs,
+			Prefix:  []string{"data", "tfe_outputs"},
+			Outputs: []string{"values", "nonsensitive_values"},
+		},
+		{
+			// Terragrunt
+			//	dependency.<name>.outputs.<output>
+			Name:    consumerKindDependency,
+			Prefix:  []string{"dependency"},
+			Outputs: []string{"outputs"},
+		},
+	}
+}
+
+// address returns the address of a consumer such as "data.terraform_remote_state.vpc".
+func (k *consumerKind) address(name string) string {
+	return strings.Join(k.Prefix, ".") + "." + name
+}
+
+// matchPrefix returns true if a traversal starts with the prefix of the kind.
+func (k *consumerKind) matchPrefix(traversal hcl.Traversal) bool {
+	if len(traversal) < len(k.Prefix) || traversal.RootName() != k.Prefix[0] {
+		return false
+	}
+	for i, name := range k.Prefix[1:] {
+		if traverseName(traversal[i+1]) != name {
+			return false
+		}
+	}
+	return true
+}
+
+// handleReferences is called for each file including references to changed outputs.
+type handleReferences func(dir *consumerDir, file *tfFile, refs []*Reference) error
+
+func findCaller(logger *slog.Logger, dirs map[string]*consumerDir, changedOutputs map[string]string, handle handleReferences) error {
+	// Find files referring terraform_remote_state
+	for _, dirPath := range slices.Sorted(maps.Keys(dirs)) {
+		dir := dirs[dirPath]
+		if len(dir.States) == 0 {
+			continue
+		}
+		states := make(map[string]*remoteState, len(dir.States))
+		for _, state := range dir.States {
+			states[state.Kind+"."+state.Name] = state
+		}
+		for _, file := range dir.Files {
+			if !containsConsumer(file.Content) {
+				continue
+			}
+			refs, err := findReferences(file, states, changedOutputs)
+			if err != nil {
+				slogerr.WithError(logger, err).Warn("find references to terraform_remote_state", "file", file.Path)
+				continue
+			}
+			if len(refs) == 0 {
+				continue
+			}
+			if err := handle(dir, file, refs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// containsConsumer returns true if a file content may include references to consumers.
+func containsConsumer(content string) bool {
+	for _, kind := range consumerKinds() {
+		if strings.Contains(content, strings.Join(kind.Prefix, ".")+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// findReferences walks all expressions in a file and returns references to outputs of given consumers such as terraform_remote_state data sources.
+// states is a map of the kind and the name of consumers joined with ".".
+// If changedOutputs is empty, references to any outputs are returned.
+func findReferences(file *tfFile, states map[string]*remoteState, changedOutputs map[string]string) ([]*Reference, error) {
+	f, diags := hclsyntax.ParseConfig(file.Byte, file.Path, hcl.Pos{Byte: 0, Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	body, ok := f.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, errors.New("convert file body to body type")
+	}
+	kinds := consumerKinds()
+	refs := []*Reference{}
+	hclsyntax.VisitAll(body, func(node hclsyntax.Node) hcl.Diagnostics {
+		var ref *Reference
+		for _, kind := range kinds {
+			switch expr := node.(type) {
+			case *hclsyntax.ScopeTraversalExpr:
+				ref = kind.parseReference(expr.Traversal)
+			case *hclsyntax.RelativeTraversalExpr:
+				ref = kind.parseDynamicReference(expr, file.Byte)
+			}
+			if ref != nil {
+				break
+			}
+		}
+		if ref == nil {
+			return nil
+		}
+		state, ok := states[ref.Kind+"."+ref.DataSource]
+		if !ok || !state.matchInstance(ref) {
+			return nil
+		}
+		if len(changedOutputs) == 0 {
+			refs = append(refs, ref)
+			return nil
+		}
+		kind, ok := changedOutputs[ref.Output]
+		if !ok {
+			return nil
+		}
+		ref.Change = kind
+		refs = append(refs, ref)
+		return nil
+	})
+	slices.SortFunc(refs, func(a, b *Reference) int {
+		return cmp.Or(cmp.Compare(a.Range.Line, b.Range.Line), cmp.Compare(a.Range.Column, b.Range.Column))
+	})
+	return refs, nil
+}
+
+// parseReference parses a traversal and returns a reference to an output of a consumer.
+// If the traversal isn't a reference to an output, it returns nil.
+//
+//	data.terraform_remote_state.<name>.outputs.<output>
+//	data.terraform_remote_state.<name>.outputs["<output>"]
+//	data.terraform_remote_state.<name>["<instance key>"].outputs.<output>
+func (k *consumerKind) parseReference(traversal hcl.Traversal) *Reference {
+	if len(traversal) < len(k.Prefix)+3 || !k.matchPrefix(traversal) { //nolint:mnd
+		return nil
+	}
+	name := traverseName(traversal[len(k.Prefix)])
+	if name == "" {
+		return nil
+	}
+	instanceKey := ""
+	rest := traversal[len(k.Prefix)+1:]
+	if index, ok := rest[0].(hcl.TraverseIndex); ok {
+		instanceKey = instanceKeyString(index.Key)
+		if instanceKey == "" {
+			return nil
+		}
+		rest = rest[1:]
+	}
+	if len(rest) < 2 || !slices.Contains(k.Outputs, traverseName(rest[0])) { //nolint:mnd
+		return nil
+	}
+	output := traverseName(rest[1])
+	if output == "" {
+		return nil
+	}
+	address := k.address(name)
+	if instanceKey != "" {
+		address += "[" + instanceKey + "]"
+	}
+	return &Reference{
+		Address:     address + "." + traverseName(rest[0]) + "." + output,
+		DataSource:  name,
+		InstanceKey: instanceKey,
+		Output:      output,
+		Range:       newRange(hcl.RangeBetween(traversal[0].SourceRange(), rest[1].SourceRange())),
+		Kind:        k.Name,
+	}
+}
+
+// parseDynamicReference parses a reference whose instance key is computed dynamically.
+// If the expression isn't a reference to an output, it returns nil.
+//
+//	data.terraform_remote_state.<name>[each.key].outputs.<output>
+func (k *consumerKind) parseDynamicReference(expr *hclsyntax.RelativeTraversalExpr, src []byte) *Reference {
+	index, ok := expr.Source.(*hclsyntax.IndexExpr)
+	if !ok {
+		return nil
+	}
+	collection, ok := index.Collection.(*hclsyntax.ScopeTraversalExpr)
+	if !ok {
+		return nil
+	}
+	traversal := collection.Traversal
+	if len(traversal) != len(k.Prefix)+1 || !k.matchPrefix(traversal) {
+		return nil
+	}
+	name := traverseName(traversal[len(k.Prefix)])
+	if name == "" || len(expr.Traversal) < 2 || !slices.Contains(k.Outputs, traverseName(expr.Traversal[0])) { //nolint:mnd
+		return nil
+	}
+	output := traverseName(expr.Traversal[1])
+	if output == "" {
+		return nil
+	}
+	instanceKey := string(index.Key.Range().SliceBytes(src))
+	return &Reference{
+		Address:     k.address(name) + "[" + instanceKey + "]." + traverseName(expr.Traversal[0]) + "." + output,
+		DataSource:  name,
+		InstanceKey: instanceKey,
+		Output:      output,
+		Range:       newRange(hcl.RangeBetween(traversal[0].SourceRange(), expr.Traversal[1].SourceRange())),
+		dynamic:     true,
+		Kind:        k.Name,
+	}
+}
+
+// matchInstance returns true if a reference refers to an instance matching with the backend.
+// If the instance key is computed dynamically, it can't be checked so it returns true.
+func (rs *remoteState) matchInstance(ref *Reference) bool {
+	if rs.InstanceKeys == nil {
+		return ref.InstanceKey == ""
+	}
+	if ref.dynamic {
+		return true
+	}
+	_, ok := rs.InstanceKeys[ref.InstanceKey]
+	return ok
+}
+
+// traverseName returns an attribute name or a string index key of a traversal step.
+// Otherwise, it returns an empty string.
+func traverseName(step hcl.Traverser) string {
+	switch t := step.(type) {
+	case hcl.TraverseAttr:
+		return t.Name
+	case hcl.TraverseIndex:
+		if t.Key.Type().Equals(cty.String) && t.Key.IsKnown() && !t.Key.IsNull() {
+			return t.Key.AsString()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,186 @@
+package tfrstate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/spf13/afero"
+)
+
+// Codes of diagnostics.
+const (
+	// DiagnosticCodeParseError means a file can't be parsed.
+	DiagnosticCodeParseError = "parse_error"
+	// DiagnosticCodeMissingAttribute means a required attribute such as backend and config of terraform_remote_state isn't found.
+	DiagnosticCodeMissingAttribute = "missing_attribute"
+	// DiagnosticCodeDuplicateBackend means a Terraform Module has multiple backend configurations.
+	DiagnosticCodeDuplicateBackend = "duplicate_backend"
+	// DiagnosticCodeInvalidBackend means a backend configuration is invalid or unsupported.
+	DiagnosticCodeInvalidBackend = "invalid_backend"
+	// DiagnosticCodeConfigMismatch means an attribute of the config of terraform_remote_state is different from the backend of the producer.
+	DiagnosticCodeConfigMismatch = "config_mismatch"
+	// DiagnosticCodeMissingConfig means the config of terraform_remote_state doesn't set an attribute which the backend of the producer sets.
+	DiagnosticCodeMissingConfig = "missing_config"
+	// DiagnosticCodeUnknownConfig means the config of terraform_remote_state has an attribute which the backend type doesn't support.
+	DiagnosticCodeUnknownConfig = "unknown_config"
+)
+
+// Diagnostic is a problem found during the analysis.
+// Diagnostics let you distinguish "no consumers" from "some files can't be analyzed".
+type Diagnostic struct {
+	// Severity is either "error" or "warning".
+	Severity string `json:"severity"`
+	Code     string `json:"code"`
+	// File is a relative path from the base directory.
+	File    string `json:"file,omitempty"`
+	Range   *Range `json:"range,omitempty"`
+	Summary string `json:"summary"`
+	Detail  string `json:"detail,omitempty"`
+	// HCL is the original diagnostic. It's useful to output diagnostics with source snippets by hcl.NewDiagnosticTextWriter.
+	HCL *hcl.Diagnostic `json:"-"`
+}
+
+// diagnosticExtra is stored in hcl.Diagnostic.Extra to keep the code of the diagnostic.
+type diagnosticExtra struct {
+	code string
+	// wrapped is the original extra information such as hclsyntax.FunctionCallDiagExtra.
+	wrapped any
+}
+
+// UnwrapDiagnosticExtra implements hcl.DiagnosticExtraUnwrapper so that hcl.DiagnosticExtra can find the original extra information.
+func (e *diagnosticExtra) UnwrapDiagnosticExtra() any {
+	return e.wrapped
+}
+
+// newDiagnostic creates a diagnostic with a code.
+func newDiagnostic(severity hcl.DiagnosticSeverity, code, summary, detail string, subject *hcl.Range) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: severity,
+		Summary:  summary,
+		Detail:   detail,
+		Subject:  subject,
+		Extra:    &diagnosticExtra{code: code},
+	}
+}
+
+// toDiagnostics converts an error to error diagnostics with a code.
+// If err is hcl.Diagnostics, the code is set to diagnostics without a code.
+// The original extra information of diagnostics such as function call errors is wrapped.
+// Otherwise, the diagnostic has only the file path as the location.
+func toDiagnostics(err error, code, filePath string) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	if !errors.As(err, &diags) {
+		return hcl.Diagnostics{
+			newDiagnostic(hcl.DiagError, code, err.Error(), "", &hcl.Range{Filename: filePath}),
+		}
+	}
+	for _, diag := range diags {
+		if diagnosticCode(diag) == "" {
+			diag.Extra = &diagnosticExtra{code: code, wrapped: diag.Extra}
+		}
+		if diag.Subject == nil {
+			diag.Subject = &hcl.Range{Filename: filePath}
+		}
+	}
+	return diags
+}
+
+// diagnosticCode returns the code of a diagnostic.
+func diagnosticCode(diag *hcl.Diagnostic) string {
+	if extra, ok := diag.Extra.(*diagnosticExtra); ok {
+		return extra.code
+	}
+	return ""
+}
+
+// newDiagnostics converts HCL diagnostics to Diagnostic.
+// File paths are converted to relative paths from baseDir.
+// Diagnostics without a code are treated as DiagnosticCodeParseError because every diagnostic must have a code.
+func newDiagnostics(pwd, baseDir string, diags hcl.Diagnostics) []*Diagnostic {
+	ret := make([]*Diagnostic, len(diags))
+	for i, diag := range diags {
+		code := diagnosticCode(diag)
+		if code == "" {
+			code = DiagnosticCodeParseError
+		}
+		d := &Diagnostic{
+			Severity: "error",
+			Code:     code,
+			Summary:  diag.Summary,
+			Detail:   diag.Detail,
+			HCL:      diag,
+		}
+		if diag.Severity == hcl.DiagWarning {
+			d.Severity = "warning"
+		}
+		if diag.Subject != nil {
+			d.File = diag.Subject.Filename
+			if rel, err := filepath.Rel(absPath(pwd, baseDir), absPath(pwd, d.File)); err == nil {
+				d.File = rel
+			}
+			if diag.Subject.Start.Line > 0 {
+				d.Range = newRange(*diag.Subject)
+			}
+		}
+		ret[i] = d
+	}
+	return ret
+}
+
+// diagnosticsWidth is the width to wrap messages of diagnostics.
+const diagnosticsWidth = 78
+
+// WriteDiagnostics outputs diagnostics with source snippets like Terraform.
+// Source files are read again from afs to render snippets.
+// File paths are output as Diagnostic.File, that is, relative paths from the base directory.
+func WriteDiagnostics(w io.Writer, afs afero.Fs, diags []*Diagnostic) error {
+	if w == nil || len(diags) == 0 {
+		return nil
+	}
+	files := map[string]*hcl.File{}
+	hclDiags := make(hcl.Diagnostics, len(diags))
+	for i, diag := range diags {
+		hclDiags[i] = diag.HCL
+		if diag.HCL.Subject == nil {
+			continue
+		}
+		// Copy the diagnostic not to change the original one
+		hclDiag := *diag.HCL
+		subject := *hclDiag.Subject
+		path := subject.Filename
+		subject.Filename = diag.File
+		hclDiag.Subject = &subject
+		if hclDiag.Context != nil {
+			hclContext := *hclDiag.Context
+			hclContext.Filename = diag.File
+			hclDiag.Context = &hclContext
+		}
+		hclDiags[i] = &hclDiag
+		if _, ok := files[diag.File]; ok {
+			continue
+		}
+		b, err := afero.ReadFile(afs, path)
+		if err != nil {
+			// The snippet is omitted if the file can't be read.
+			continue
+		}
+		files[diag.File] = &hcl.File{Bytes: b}
+	}
+	if err := hcl.NewDiagnosticTextWriter(w, files, diagnosticsWidth, false).WriteDiagnostics(hclDiags); err != nil {
+		return fmt.Errorf("output diagnostics: %w", err)
+	}
+	return nil
+}
+
+// HasErrors returns true if diagnostics include errors.
+func HasErrors(diags []*Diagnostic) bool {
+	for _, diag := range diags {
+		if diag.Severity == "error" {
+			return true
+		}
+	}
+	return false
+}
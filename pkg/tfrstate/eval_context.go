@@ -0,0 +1,295 @@
+package tfrstate
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// ParseVars parses variables given by command line options such as --var.
+// Each variable must be <name>=<value>.
+//
+//	env=prod
+func ParseVars(vars []string) (map[string]string, error) {
+	m := make(map[string]string, len(vars))
+	for _, v := range vars {
+		name, value, ok := strings.Cut(v, "=")
+		if !ok {
+			return nil, slogerr.With(errors.New("--var must be <name>=<value>"), "var", v) //nolint:wrapcheck
+		}
+		m[name] = value
+	}
+	return m, nil
+}
+
+// parseCLIVars parses variables given by variable definitions files and key-value pairs.
+// Key-value pairs take precedence over variable definitions files.
+// Relative paths of variable definitions files are relative to pwd.
+func parseCLIVars(afs afero.Fs, pwd string, varFiles []string, vars map[string]string) (map[string]cty.Value, error) {
+	values := map[string]cty.Value{}
+	for _, varFile := range varFiles {
+		if err := readAttributesFile(afs, absPath(pwd, varFile), values); err != nil {
+			return nil, fmt.Errorf("read a variable file: %w", slogerr.With(err, "var_file", varFile))
+		}
+	}
+	for name, value := range vars {
+		values[name] = cty.StringVal(value)
+	}
+	return values, nil
+}
+
+// readAttributesFile reads top level attributes of an HCL or JSON file into values.
+// It reads variable definitions files such as terraform.tfvars and *.auto.tfvars.json and backend configuration files such as *.tfbackend.
+func readAttributesFile(afs afero.Fs, path string, values map[string]cty.Value) error {
+	src, err := afero.ReadFile(afs, path)
+	if err != nil {
+		return fmt.Errorf("read a file: %w", err)
+	}
+	parser := hclparse.NewParser()
+	var file *hcl.File
+	var diags hcl.Diagnostics
+	if strings.HasSuffix(path, ".json") {
+		file, diags = parser.ParseJSON(src, path)
+	} else {
+		file, diags = parser.ParseHCL(src, path)
+	}
+	if diags.HasErrors() {
+		return diags
+	}
+	attrs, diags := file.Body.JustAttributes()
+	if diags.HasErrors() {
+		return diags
+	}
+	for name, attr := range attrs {
+		val, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			return diags
+		}
+		values[name] = val
+	}
+	return nil
+}
+
+// newEvalContext creates an evaluation context of a Terraform module.
+// The context has variables (var.*) and local values (local.*).
+// Variables are resolved from default values, terraform.tfvars, *.auto.tfvars, and cliVars in this order.
+// Variables and local values which can't be resolved are unknown.
+func newEvalContext(logger *slog.Logger, afs afero.Fs, dir string, cliVars map[string]cty.Value) (*hcl.EvalContext, error) {
+	tfFiles, err := afero.Glob(afs, filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, fmt.Errorf("glob *.tf: %w", err)
+	}
+	vars := map[string]cty.Value{}
+	locals := map[string]hcl.Expression{}
+	for _, tfFile := range tfFiles {
+		if err := readModuleValues(afs, tfFile, vars, locals); err != nil {
+			slogerr.WithError(logger, err).Warn("read variables and local values", "file", tfFile)
+		}
+	}
+	varFiles, err := findVarFiles(afs, dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, varFile := range varFiles {
+		if err := readAttributesFile(afs, varFile, vars); err != nil {
+			slogerr.WithError(logger, err).Warn("read a variable file", "var_file", varFile)
+		}
+	}
+	for name, val := range cliVars {
+		if _, ok := vars[name]; ok {
+			vars[name] = val
+		}
+	}
+	evalCtx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var": cty.ObjectVal(vars),
+			"path": cty.ObjectVal(map[string]cty.Value{
+				"module": cty.StringVal("."),
+				"root":   cty.StringVal("."),
+				"cwd":    cty.StringVal("."),
+			}),
+			"terraform": cty.ObjectVal(map[string]cty.Value{
+				"workspace": cty.StringVal("default"),
+			}),
+		},
+		Functions: functions(),
+	}
+	evalCtx.Variables["local"] = evalLocals(evalCtx, locals)
+	return evalCtx, nil
+}
+
+// findVarFiles returns variable definitions files which Terraform loads automatically.
+func findVarFiles(afs afero.Fs, dir string) ([]string, error) {
+	varFiles := []string{}
+	for _, name := range []string{"terraform.tfvars", "terraform.tfvars.json"} {
+		p := filepath.Join(dir, name)
+		if f, err := afero.Exists(afs, p); err != nil {
+			return nil, fmt.Errorf("check if a file exists: %w", slogerr.With(err, "file", p))
+		} else if f {
+			varFiles = append(varFiles, p)
+		}
+	}
+	for _, pattern := range []string{"*.auto.tfvars", "*.auto.tfvars.json"} {
+		matches, err := afero.Glob(afs, filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("glob %s: %w", pattern, err)
+		}
+		slices.Sort(matches)
+		varFiles = append(varFiles, matches...)
+	}
+	return varFiles, nil
+}
+
+// readModuleValues reads variable blocks and locals blocks in a file.
+// Variables without default values are unknown.
+func readModuleValues(afs afero.Fs, path string, vars map[string]cty.Value, locals map[string]hcl.Expression) error {
+	src, err := afero.ReadFile(afs, path)
+	if err != nil {
+		return fmt.Errorf("read a file: %w", err)
+	}
+	file, diags := hclsyntax.ParseConfig(src, path, hcl.Pos{Byte: 0, Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return diags
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return errors.New("convert file body to body type")
+	}
+	for _, block := range body.Blocks {
+		switch block.Type {
+		case "variable":
+			if len(block.Labels) != 1 {
+				continue
+			}
+			vars[block.Labels[0]] = cty.DynamicVal
+			attr, ok := block.Body.Attributes["default"]
+			if !ok {
+				continue
+			}
+			val, diags := attr.Expr.Value(nil)
+			if diags.HasErrors() {
+				continue
+			}
+			vars[block.Labels[0]] = val
+		case "locals":
+			for name, attr := range block.Body.Attributes {
+				locals[name] = attr.Expr
+			}
+		}
+	}
+	return nil
+}
+
+// evalLocals evaluates local values.
+// Local values can refer other local values, so they are evaluated repeatedly until no more local values are resolved.
+// Local values which can't be resolved are unknown.
+func evalLocals(evalCtx *hcl.EvalContext, exprs map[string]hcl.Expression) cty.Value {
+	values := make(map[string]cty.Value, len(exprs))
+	for {
+		evalCtx.Variables["local"] = cty.ObjectVal(values)
+		resolved := false
+		for name, expr := range exprs {
+			if _, ok := values[name]; ok {
+				continue
+			}
+			val, diags := expr.Value(evalCtx)
+			if diags.HasErrors() {
+				continue
+			}
+			values[name] = val
+			resolved = true
+		}
+		if !resolved {
+			break
+		}
+	}
+	for name := range exprs {
+		if _, ok := values[name]; !ok {
+			values[name] = cty.DynamicVal
+		}
+	}
+	return cty.ObjectVal(values)
+}
+
+// evalValue evaluates an expression and returns an error if the value isn't wholly known.
+func evalValue(expr hcl.Expression, evalCtx *hcl.EvalContext) (cty.Value, error) {
+	val, diags := expr.Value(evalCtx)
+	if diags.HasErrors() {
+		return cty.NilVal, diags
+	}
+	if !val.IsWhollyKnown() {
+		return cty.NilVal, hcl.Diagnostics{
+			{
+				Severity: hcl.DiagError,
+				Summary:  "Unresolved value",
+				Detail:   "The value depends on variables or local values which can't be resolved statically.",
+				Subject:  expr.Range().Ptr(),
+			},
+		}
+	}
+	return val, nil
+}
+
+// evalString evaluates an expression as a string.
+func evalString(expr hcl.Expression, evalCtx *hcl.EvalContext) (string, error) {
+	val, err := evalValue(expr, evalCtx)
+	if err != nil {
+		return "", err
+	}
+	if val.IsNull() {
+		return "", nil
+	}
+	s, err := convert.Convert(val, cty.String)
+	if err != nil {
+		return "", hcl.Diagnostics{
+			{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid value",
+				Detail:   "The value must be a string: " + err.Error(),
+				Subject:  expr.Range().Ptr(),
+			},
+		}
+	}
+	return s.AsString(), nil
+}
+
+// functions returns a subset of Terraform built-in functions which are often used to build backend configurations.
+func functions() map[string]function.Function {
+	return map[string]function.Function{
+		"coalesce":   stdlib.CoalesceFunc,
+		"concat":     stdlib.ConcatFunc,
+		"element":    stdlib.ElementFunc,
+		"format":     stdlib.FormatFunc,
+		"join":       stdlib.JoinFunc,
+		"keys":       stdlib.KeysFunc,
+		"length":     stdlib.LengthFunc,
+		"lookup":     stdlib.LookupFunc,
+		"lower":      stdlib.LowerFunc,
+		"merge":      stdlib.MergeFunc,
+		"replace":    stdlib.ReplaceFunc,
+		"split":      stdlib.SplitFunc,
+		"substr":     stdlib.SubstrFunc,
+		"tolist":     stdlib.MakeToFunc(cty.List(cty.DynamicPseudoType)),
+		"tomap":      stdlib.MakeToFunc(cty.Map(cty.DynamicPseudoType)),
+		"toset":      stdlib.MakeToFunc(cty.Set(cty.DynamicPseudoType)),
+		"tostring":   stdlib.MakeToFunc(cty.String),
+		"trimprefix": stdlib.TrimPrefixFunc,
+		"trimspace":  stdlib.TrimSpaceFunc,
+		"trimsuffix": stdlib.TrimSuffixFunc,
+		"upper":      stdlib.UpperFunc,
+		"values":     stdlib.ValuesFunc,
+	}
+}
@@ -1,4 +1,4 @@
-package find
+package tfrstate
 
 import (
 	"fmt"
@@ -9,11 +9,12 @@ import (
 	"github.com/spf13/afero"
 )
 
+// findTFFiles finds *.tf and terragrunt.hcl in baseDir.
 func findTFFiles(afs afero.Fs, baseDir string) ([]string, error) {
-	// Find **/*.tf
+	// Find **/*.tf and **/terragrunt.hcl
 	tfFiles := []string{}
-	ignorePatterns := []string{".terraform", ".git", ".github", "vendor", "node_modules"}
-	if err := doublestar.GlobWalk(afero.NewIOFS(afs), filepath.Join(baseDir, "**/*.tf"), func(path string, _ fs.DirEntry) error {
+	ignorePatterns := []string{".terraform", ".terragrunt-cache", ".git", ".github", "vendor", "node_modules"}
+	if err := doublestar.GlobWalk(afero.NewIOFS(afs), filepath.Join(baseDir, "**/{*.tf,"+terragruntFileName+"}"), func(path string, _ fs.DirEntry) error {
 		if err := ignorePath(path, ignorePatterns); err != nil {
 			return err
 		}
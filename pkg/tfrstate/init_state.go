@@ -0,0 +1,88 @@
+package tfrstate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Modes of reading .terraform/terraform.tfstate, where terraform init records the backend configuration.
+const (
+	// InitStateFallback uses .terraform/terraform.tfstate if the backend block isn't found,
+	// and complements attributes which the backend block doesn't set or can't resolve statically.
+	InitStateFallback = "fallback"
+	// InitStatePrefer uses .terraform/terraform.tfstate instead of the backend block if it exists.
+	InitStatePrefer = "prefer"
+	// InitStateIgnore doesn't read .terraform/terraform.tfstate.
+	InitStateIgnore = "ignore"
+)
+
+// validateInitState validates the mode of reading .terraform/terraform.tfstate.
+func validateInitState(mode string) error {
+	switch mode {
+	case "", InitStateFallback, InitStatePrefer, InitStateIgnore:
+		return nil
+	}
+	return slogerr.With(errors.New("the init state mode must be one of fallback, prefer, and ignore"), "init_state", mode) //nolint:wrapcheck
+}
+
+// initState is .terraform/terraform.tfstate created by terraform init.
+//
+//	{
+//	  "version": 3,
+//	  "backend": {
+//	    "type": "s3",
+//	    "config": {
+//	      "bucket": "mybucket",
+//	      "key": "network/terraform.tfstate",
+//	      "region": "us-east-1"
+//	    }
+//	  }
+//	}
+type initState struct {
+	Backend *struct {
+		Type   string          `json:"type"`
+		Config json.RawMessage `json:"config"`
+	} `json:"backend"`
+}
+
+// initStatePath returns the path of .terraform/terraform.tfstate in dir.
+func initStatePath(dir string) string {
+	return filepath.Join(dir, ".terraform", "terraform.tfstate")
+}
+
+// readInitState returns the backend type and the configuration recorded by terraform init in dir.
+// The cloud block is treated as the remote backend.
+// If .terraform/terraform.tfstate doesn't exist or it has no backend, the backend type is empty.
+func readInitState(afs afero.Fs, dir string) (string, map[string]cty.Value, error) {
+	p := initStatePath(dir)
+	b, err := afero.ReadFile(afs, p)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", nil, nil
+		}
+		return "", nil, fmt.Errorf("read a file: %w", slogerr.With(err, "file", p))
+	}
+	state := &initState{}
+	if err := json.Unmarshal(b, state); err != nil {
+		return "", nil, fmt.Errorf("unmarshal .terraform/terraform.tfstate as JSON: %w", slogerr.With(err, "file", p))
+	}
+	if state.Backend == nil || state.Backend.Type == "" {
+		return "", nil, nil
+	}
+	config, err := unmarshalBackendJSON(state.Backend.Config)
+	if err != nil {
+		return "", nil, slogerr.With(err, "file", p) //nolint:wrapcheck
+	}
+	backendType := state.Backend.Type
+	if backendType == "cloud" {
+		backendType = BackendTypeRemote
+	}
+	return backendType, config, nil
+}
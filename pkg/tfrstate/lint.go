@@ -0,0 +1,381 @@
+package tfrstate
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/spf13/afero"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// LintOptions is options of Lint.
+type LintOptions struct {
+	// WorkDir is the absolute path of the working directory.
+	// Relative paths in LintOptions are relative to WorkDir.
+	WorkDir string
+	// BaseDir is the directory where producers and consumers are searched.
+	BaseDir string
+	// Vars are variables used to resolve configurations of consumers and backends.
+	Vars map[string]string
+	// VarFiles are variable definitions files.
+	// Vars take precedence over VarFiles.
+	VarFiles []string
+	// InitState decides how .terraform/terraform.tfstate is used to get backends of producers.
+	// If InitState is empty, InitStateFallback is used.
+	InitState string
+	// Registry is a set of supported backend types.
+	// If Registry is nil, built-in backend types are supported.
+	Registry *Registry
+}
+
+// LintResult is the result of Lint.
+type LintResult struct {
+	// Diagnostics are problems in the config of terraform_remote_state data sources.
+	Diagnostics []*Diagnostic `json:"diagnostics"`
+	// BaseDir is the absolute path of the base directory.
+	BaseDir string `json:"base_dir"`
+}
+
+// producer is a Terraform Root Module in the base directory.
+type producer struct {
+	// Dir is the absolute path of the Terraform Root Module.
+	Dir     string
+	Backend *backendDecl
+	Bucket  *Bucket
+	// Location is a human readable location of the backend such as "network/main.tf line 3".
+	Location string
+}
+
+// Lint validates the config of terraform_remote_state data sources against the backend of the producer.
+// For each terraform_remote_state referring a producer in the base directory,
+// attributes are compared with the backend block of the producer.
+// Mismatches, missing attributes, and unknown attributes are returned as diagnostics.
+// terraform_remote_state data sources with for_each or count are skipped.
+func Lint(_ context.Context, logger *slog.Logger, afs afero.Fs, opts *LintOptions) (*LintResult, error) {
+	if err := validateInitState(opts.InitState); err != nil {
+		return nil, err
+	}
+	registry := opts.Registry
+	if registry == nil {
+		registry = NewRegistry()
+	}
+	baseDir := absPath(opts.WorkDir, opts.BaseDir)
+	cliVars, err := parseCLIVars(afs, opts.WorkDir, opts.VarFiles, opts.Vars)
+	if err != nil {
+		return nil, err
+	}
+	tfFiles, err := findTFFiles(afs, baseDir)
+	if err != nil {
+		return nil, err
+	}
+	var diags hcl.Diagnostics
+
+	producers, ds, err := findProducers(logger, afs, registry, opts.WorkDir, baseDir, tfFiles, cliVars, opts.InitState)
+	diags = diags.Extend(ds)
+	if err != nil {
+		return nil, err
+	}
+	logger.Debug("found producers", "num_of_producers", len(producers))
+
+	dirs := map[string]*consumerDir{}
+	if err := filterFilesWithRemoteState(afs, tfFiles, dirs); err != nil {
+		return nil, err
+	}
+	for _, dirPath := range slices.Sorted(maps.Keys(dirs)) {
+		dir := dirs[dirPath]
+		moduleDir := absPath(opts.WorkDir, dir.Path)
+		evalCtx, err := newEvalContext(logger, afs, dir.Path, cliVars)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range dir.Files {
+			if isTerragruntFile(file.Path) {
+				continue
+			}
+			diags = diags.Extend(lintRemoteStates(logger.With("file", file.Path), registry, file, moduleDir, evalCtx, producers))
+		}
+	}
+	return &LintResult{
+		Diagnostics: newDiagnostics(opts.WorkDir, opts.BaseDir, diags),
+		BaseDir:     baseDir,
+	}, nil
+}
+
+// findProducers finds backends of Terraform Root Modules in directories including tfFiles.
+func findProducers(logger *slog.Logger, afs afero.Fs, registry *Registry, pwd, baseDir string, tfFiles []string, cliVars map[string]cty.Value, initStateMode string) ([]*producer, hcl.Diagnostics, error) {
+	dirs := map[string]struct{}{}
+	for _, tfFile := range tfFiles {
+		dirs[absPath(pwd, filepath.Dir(tfFile))] = struct{}{}
+	}
+	var producers []*producer
+	var diags hcl.Diagnostics
+	for _, dir := range slices.Sorted(maps.Keys(dirs)) {
+		// Producers whose backend can't be resolved are ignored, and the other producers are still checked
+		decl, ds, err := findBackend(logger, afs, dir, cliVars, nil, initStateMode)
+		diags = diags.Extend(ds)
+		if err != nil {
+			diags = diags.Extend(newBackendError(err, baseDir, dir))
+			continue
+		}
+		if decl == nil {
+			continue
+		}
+		bucket, ds, err := newBackendBucket(registry, decl.Type, decl.Config, dir)
+		diags = diags.Extend(ds)
+		if err != nil {
+			diags = diags.Extend(newBackendError(err, baseDir, dir))
+			continue
+		}
+		if bucket == nil {
+			continue
+		}
+		location, err := filepath.Rel(baseDir, dir)
+		if err != nil {
+			return nil, diags, fmt.Errorf("get a relative path from baseDir to dir: %w", err)
+		}
+		if decl.Range.Filename != "" {
+			if rel, err := filepath.Rel(baseDir, decl.Range.Filename); err == nil {
+				location = fmt.Sprintf("%s line %d", rel, decl.Range.Start.Line)
+			}
+		}
+		producers = append(producers, &producer{
+			Dir:      dir,
+			Backend:  decl,
+			Bucket:   bucket,
+			Location: location,
+		})
+	}
+	return producers, diags, nil
+}
+
+// newBackendError converts an error getting the backend of a producer to diagnostics.
+// dir is output as a relative path from baseDir.
+func newBackendError(err error, baseDir, dir string) hcl.Diagnostics {
+	if rel, e := filepath.Rel(baseDir, dir); e == nil {
+		dir = rel
+	}
+	return hcl.Diagnostics{
+		newDiagnostic(hcl.DiagError, DiagnosticCodeInvalidBackend,
+			"Failed to get the backend configuration",
+			fmt.Sprintf("The backend configuration of %s can't be read, so consumers of the Terraform State aren't checked: %s", dir, err), nil),
+	}
+}
+
+// lintRemoteStates validates terraform_remote_state data sources in a file.
+func lintRemoteStates(logger *slog.Logger, registry *Registry, file *tfFile, moduleDir string, evalCtx *hcl.EvalContext, producers []*producer) hcl.Diagnostics {
+	body, err := parseHCLBody(file.Byte, file.Path)
+	if err != nil {
+		return toDiagnostics(err, DiagnosticCodeParseError, file.Path)
+	}
+	var diags hcl.Diagnostics
+	for _, block := range body.Blocks {
+		if block.Type != "data" || len(block.Labels) != 2 || block.Labels[0] != consumerKindRemoteState {
+			continue
+		}
+		_, hasForEach := block.Body.Attributes["for_each"]
+		_, hasCount := block.Body.Attributes["count"]
+		if hasForEach || hasCount {
+			logger.Debug("terraform_remote_state with for_each or count is skipped", "data_source", block.Labels[1])
+			continue
+		}
+		diags = diags.Extend(lintRemoteState(registry, block, moduleDir, evalCtx, producers))
+	}
+	return diags
+}
+
+// lintRemoteState validates the config of a terraform_remote_state data source against the backend of the producer.
+// If the config can't be resolved statically, it returns nil.
+// If the producer isn't found, only unknown arguments are reported.
+//
+//	data "terraform_remote_state" "vpc" {
+//	  backend = "s3"
+//	  config = {
+//	    bucket = "terraform-state"
+//	    key    = "vpc/terraform.tfstate"
+//	    region = "us-east-1"
+//	  }
+//	}
+func lintRemoteState(registry *Registry, block *hclsyntax.Block, moduleDir string, evalCtx *hcl.EvalContext, producers []*producer) hcl.Diagnostics {
+	backendAttr, ok := block.Body.Attributes["backend"]
+	if !ok {
+		return nil
+	}
+	backendType, err := evalString(backendAttr.Expr, evalCtx)
+	if err != nil {
+		return nil
+	}
+	bt, ok := registry.Get(backendType)
+	if !ok {
+		return nil
+	}
+	configAttr, ok := block.Body.Attributes["config"]
+	if !ok {
+		return nil
+	}
+	configVal, ds := configAttr.Expr.Value(evalCtx)
+	if ds.HasErrors() || configVal.IsNull() || !configVal.IsKnown() || !(configVal.Type().IsObjectType() || configVal.Type().IsMapType()) {
+		return nil
+	}
+	config := configVal.AsValueMap()
+
+	// Unknown arguments are checked before the producer is looked up
+	// because a misspelled identity attribute such as "kee" never matches any producer.
+	name := strings.Join(block.Labels, ".")
+	var diags hcl.Diagnostics
+	if len(bt.Attributes) != 0 {
+		for _, key := range slices.Sorted(maps.Keys(config)) {
+			if slices.Contains(bt.Attributes, key) {
+				continue
+			}
+			diags = diags.Append(newDiagnostic(hcl.DiagError, DiagnosticCodeUnknownConfig,
+				"Unsupported argument",
+				fmt.Sprintf("An argument named %q is not expected in the config of data.%s. The backend type %s doesn't support it.", key, name, backendType),
+				configItemRange(configAttr.Expr, key).Ptr()))
+		}
+	}
+
+	bucket, err := registry.Bucket(backendType, config, moduleDir)
+	if err != nil {
+		return diags
+	}
+	var p *producer
+	for _, pr := range producers {
+		if pr.Bucket.Compare(bucket) {
+			p = pr
+			break
+		}
+	}
+	if p == nil {
+		return diags
+	}
+
+	// top level attributes compared by LintFields
+	covered := map[string]struct{}{}
+	for _, field := range bt.LintFields {
+		covered[topLevelField(field)] = struct{}{}
+		for alias, target := range bt.Aliases {
+			if target == field {
+				covered[topLevelField(alias)] = struct{}{}
+			}
+		}
+		pv, ok := lintLookup(bt, p.Backend.Config, field)
+		if !ok {
+			continue
+		}
+		cv, ok := lintLookup(bt, config, field)
+		if !ok {
+			diags = diags.Append(newDiagnostic(hcl.DiagWarning, DiagnosticCodeMissingConfig,
+				"Missing argument",
+				fmt.Sprintf("%s isn't set in the config of data.%s, but the backend of the producer (%s) sets %s.", field, name, p.Location, displayValue(pv)),
+				configAttr.Expr.Range().Ptr()))
+			continue
+		}
+		if !equalConfigValue(pv, cv) {
+			diags = diags.Append(newConfigMismatch(configAttr.Expr, name, field, cv, pv, p))
+		}
+	}
+
+	// other attributes set in both of the consumer and the producer
+	identity := map[string]struct{}{}
+	for _, field := range bt.IdentityFields {
+		identity[topLevelField(field)] = struct{}{}
+	}
+	for _, key := range slices.Sorted(maps.Keys(config)) {
+		if _, ok := covered[key]; ok {
+			continue
+		}
+		if _, ok := identity[key]; ok {
+			continue
+		}
+		pv, ok := p.Backend.Config[key]
+		if !ok || pv.IsNull() {
+			continue
+		}
+		if cv := config[key]; !cv.IsNull() && !equalConfigValue(pv, cv) {
+			diags = diags.Append(newConfigMismatch(configAttr.Expr, name, key, cv, pv, p))
+		}
+	}
+	return diags
+}
+
+// newConfigMismatch returns a diagnostic that an attribute of the config is different from the backend of the producer.
+func newConfigMismatch(expr hcl.Expression, name, field string, consumerVal, producerVal cty.Value, p *producer) *hcl.Diagnostic {
+	return newDiagnostic(hcl.DiagError, DiagnosticCodeConfigMismatch,
+		"Mismatched argument",
+		fmt.Sprintf("%s of data.%s is %s, but the backend of the producer (%s) sets %s.", field, name, displayValue(consumerVal), p.Location, displayValue(producerVal)),
+		configItemRange(expr, topLevelField(field)).Ptr())
+}
+
+// topLevelField returns the top level attribute of a nested field such as "assume_role" of "assume_role.role_arn".
+func topLevelField(field string) string {
+	first, _, _ := strings.Cut(field, ".")
+	return first
+}
+
+// lintLookup returns a field of a configuration.
+// If the field isn't set, deprecated fields replaced by the field are used.
+func lintLookup(bt *BackendType, config map[string]cty.Value, field string) (cty.Value, bool) {
+	if val, ok, err := lookupConfig(config, field); err == nil && ok && !val.IsNull() {
+		return val, true
+	}
+	for _, alias := range slices.Sorted(maps.Keys(bt.Aliases)) {
+		if bt.Aliases[alias] != field {
+			continue
+		}
+		if val, ok, err := lookupConfig(config, alias); err == nil && ok && !val.IsNull() {
+			return val, true
+		}
+	}
+	return cty.NilVal, false
+}
+
+// equalConfigValue compares attributes of configurations.
+// Primitive values are compared as strings, so true and "true" are equal.
+// If either value can't be resolved statically, they are treated as equal.
+func equalConfigValue(a, b cty.Value) bool {
+	if !a.IsWhollyKnown() || !b.IsWhollyKnown() {
+		return true
+	}
+	return displayValue(a) == displayValue(b)
+}
+
+// displayValue returns a string representation of an attribute of a configuration.
+func displayValue(val cty.Value) string {
+	if val.Type().IsPrimitiveType() {
+		if s, err := convert.Convert(val, cty.String); err == nil && s.IsKnown() && !s.IsNull() {
+			return fmt.Sprintf("%q", s.AsString())
+		}
+	}
+	b, err := ctyjson.Marshal(val, val.Type())
+	if err != nil {
+		return val.GoString()
+	}
+	return string(b)
+}
+
+// configItemRange returns the range of an item of an object constructor expression such as `region = "us-east-1"`.
+// If the item isn't found, the range of the expression is returned.
+func configItemRange(expr hcl.Expression, key string) hcl.Range {
+	obj, ok := expr.(*hclsyntax.ObjectConsExpr)
+	if !ok {
+		return expr.Range()
+	}
+	for _, item := range obj.Items {
+		if hcl.ExprAsKeyword(item.KeyExpr) == key {
+			return hcl.RangeBetween(item.KeyExpr.Range(), item.ValueExpr.Range())
+		}
+		if v, diags := item.KeyExpr.Value(nil); !diags.HasErrors() && v.Type() == cty.String && v.IsKnown() && !v.IsNull() && v.AsString() == key {
+			return hcl.RangeBetween(item.KeyExpr.Range(), item.ValueExpr.Range())
+		}
+	}
+	return expr.Range()
+}
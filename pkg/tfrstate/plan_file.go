@@ -0,0 +1,201 @@
+package tfrstate
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+
+	"github.com/spf13/afero"
+)
+
+// Kinds of output changes.
+const (
+	ChangeKindUpdated = "updated"
+	ChangeKindRemoved = "removed"
+	// ChangeKindRenamed means the output is probably renamed.
+	// The output is deleted and another output having the same value is created.
+	ChangeKindRenamed = "renamed"
+)
+
+type PlanFile struct {
+	OutputChanges map[string]*OutputChange `json:"output_changes"`
+}
+
+type OutputChange struct {
+	Actions []string `json:"actions"`
+	Before  any      `json:"before"`
+	After   any      `json:"after"`
+	// AfterUnknown is true if the value is unknown until apply.
+	// If the value is partially unknown, it's an object or an array having true in unknown elements.
+	AfterUnknown any `json:"after_unknown"`
+}
+
+// PlanOutputs is output changes read from a plan file.
+type PlanOutputs struct {
+	// Changed is a map of changed output names and their change kinds.
+	Changed map[string]string
+	// Renamed is a map of old output names and new output names of probable renames.
+	Renamed map[string]string
+}
+
+// Kind returns the kind of the output change.
+// If the output is deleted, it returns "removed".
+// Otherwise, it returns "updated".
+func (oc *OutputChange) Kind() string {
+	if len(oc.Actions) == 1 && oc.Actions[0] == "delete" {
+		return ChangeKindRemoved
+	}
+	return ChangeKindUpdated
+}
+
+// ReadPlanOutputs reads a plan file in JSON format and returns changed outputs and probable renames.
+// If a deleted output and a created output have the same value, the deleted output is treated as renamed.
+// Created outputs and unchanged outputs are excluded from changed outputs.
+func ReadPlanOutputs(afs afero.Fs, path string) (*PlanOutputs, error) {
+	planFile := &PlanFile{}
+	if err := readPlanFile(afs, path, planFile); err != nil {
+		return nil, fmt.Errorf("read a plan file: %w", err)
+	}
+	renamed := inferRenamedOutputs(planFile)
+	excludeCreatedOutputs(planFile)
+	outputs := &PlanOutputs{
+		Changed: make(map[string]string, len(planFile.OutputChanges)),
+		Renamed: renamed,
+	}
+	for name, change := range planFile.OutputChanges {
+		outputs.Changed[name] = change.Kind()
+		if _, ok := renamed[name]; ok {
+			outputs.Changed[name] = ChangeKindRenamed
+		}
+	}
+	return outputs, nil
+}
+
+// inferRenamedOutputs pairs deleted outputs and created outputs whose values are same.
+// Unknown values of created outputs match any value, but outputs whose whole value is unknown or null aren't paired.
+// Outputs aren't paired by types because the plan file doesn't have types of unknown values.
+// Only one-to-one pairs are returned because ambiguous pairs can't be distinguished.
+func inferRenamedOutputs(file *PlanFile) map[string]string {
+	var deleted, created []string
+	for _, name := range slices.Sorted(maps.Keys(file.OutputChanges)) {
+		change := file.OutputChanges[name]
+		if len(change.Actions) != 1 {
+			continue
+		}
+		switch change.Actions[0] {
+		case "delete":
+			if change.Before != nil {
+				deleted = append(deleted, name)
+			}
+		case "create":
+			if change.After != nil || isPartiallyUnknown(change.AfterUnknown) {
+				created = append(created, name)
+			}
+		}
+	}
+	candidates := make(map[string][]string, len(deleted))
+	numOfCandidates := make(map[string]int, len(created))
+	for _, from := range deleted {
+		for _, to := range created {
+			change := file.OutputChanges[to]
+			if !matchOutputValue(file.OutputChanges[from].Before, change.After, change.AfterUnknown) {
+				continue
+			}
+			candidates[from] = append(candidates[from], to)
+			numOfCandidates[to]++
+		}
+	}
+	renamed := map[string]string{}
+	for from, tos := range candidates {
+		if len(tos) == 1 && numOfCandidates[tos[0]] == 1 {
+			renamed[from] = tos[0]
+		}
+	}
+	return renamed
+}
+
+// isPartiallyUnknown returns true if after_unknown is an object or an array, which means some elements are known.
+func isPartiallyUnknown(afterUnknown any) bool {
+	switch afterUnknown.(type) {
+	case map[string]any, []any:
+		return true
+	}
+	return false
+}
+
+// matchOutputValue returns true if before equals after except for unknown elements of after.
+func matchOutputValue(before, after, afterUnknown any) bool { //nolint:cyclop
+	if unknown, ok := afterUnknown.(bool); ok && unknown {
+		return true
+	}
+	switch b := before.(type) {
+	case map[string]any:
+		// unknown attributes are omitted from after
+		a, ok := after.(map[string]any)
+		if !ok && after != nil {
+			return false
+		}
+		unknown, _ := afterUnknown.(map[string]any)
+		keys := map[string]struct{}{}
+		for k := range a {
+			keys[k] = struct{}{}
+		}
+		for k := range unknown {
+			keys[k] = struct{}{}
+		}
+		if len(keys) != len(b) {
+			return false
+		}
+		for k, v := range b {
+			if _, ok := keys[k]; !ok || !matchOutputValue(v, a[k], unknown[k]) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		a, ok := after.([]any)
+		if !ok && after != nil {
+			return false
+		}
+		unknown, _ := afterUnknown.([]any)
+		if len(a) != len(b) && len(unknown) != len(b) {
+			return false
+		}
+		for i, v := range b {
+			var av, uv any
+			if i < len(a) {
+				av = a[i]
+			}
+			if i < len(unknown) {
+				uv = unknown[i]
+			}
+			if !matchOutputValue(v, av, uv) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(before, after)
+}
+
+func excludeCreatedOutputs(file *PlanFile) {
+	for name, change := range file.OutputChanges {
+		if len(change.Actions) == 1 && (change.Actions[0] == "create" || change.Actions[0] == "no-op") {
+			delete(file.OutputChanges, name)
+		}
+	}
+}
+
+func readPlanFile(fs afero.Fs, path string, file *PlanFile) error {
+	f, err := fs.Open(path)
+	if err != nil {
+		return fmt.Errorf("open a file file: %w", err)
+	}
+	defer f.Close()
+	if err := json.NewDecoder(f).Decode(file); err != nil {
+		return fmt.Errorf("read a plan file as JSON: %w", err)
+	}
+	return nil
+}
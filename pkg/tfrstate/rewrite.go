@@ -0,0 +1,138 @@
+package tfrstate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+)
+
+// RewriteOptions is options of Rewrite.
+type RewriteOptions struct {
+	// Options decides the producer and consumers. ChangedOutputs and OnReferences are ignored.
+	Options
+	// From is the old output name.
+	From string
+	// To is the new output name.
+	To string
+}
+
+// RewriteResult is the result of Rewrite.
+type RewriteResult struct {
+	*Result
+	// Files are consumer files including references to the old output.
+	Files []*RewrittenFile `json:"files"`
+}
+
+// RewrittenFile is a consumer file whose references to the old output are rewritten.
+type RewrittenFile struct {
+	// Dir is a relative path from the base directory
+	Dir string `json:"dir"`
+	// File is a relative path from Dir
+	File string `json:"file"`
+	// Path is the file path to read and write the file.
+	Path       string       `json:"-"`
+	References []*Reference `json:"references"`
+	// Before is the original content.
+	Before []byte `json:"-"`
+	// After is the rewritten content.
+	After []byte `json:"-"`
+}
+
+// Rewrite renames an output in references of consumers of the given Terraform State.
+//
+//	data.terraform_remote_state.<name>.outputs.<from> => data.terraform_remote_state.<name>.outputs.<to>
+//
+// Consumers are found in the same way as Find.
+// Only output names are rewritten by hclwrite, so formatting and comments are kept.
+// Rewrite doesn't write files. Write RewrittenFile.After to RewrittenFile.Path.
+func Rewrite(ctx context.Context, logger *slog.Logger, afs afero.Fs, opts *RewriteOptions) (*RewriteResult, error) {
+	if opts.From == "" || opts.To == "" {
+		return nil, errors.New("old and new output names are required")
+	}
+	if !hclsyntax.ValidIdentifier(opts.To) {
+		return nil, slogerr.With(errors.New("the new output name is invalid"), "output", opts.To) //nolint:wrapcheck
+	}
+	findOpts := opts.Options
+	findOpts.ChangedOutputs = map[string]string{opts.From: ""}
+	findOpts.OnReferences = nil
+	result, err := Find(ctx, logger, afs, &findOpts)
+	if err != nil {
+		return nil, err
+	}
+	ret := &RewriteResult{
+		Result: result,
+		Files:  []*RewrittenFile{},
+	}
+	for _, change := range result.Changes {
+		for _, file := range change.Files {
+			p := filepath.Join(result.BaseDir, change.Dir, file.Path)
+			before, err := afero.ReadFile(afs, p)
+			if err != nil {
+				return nil, fmt.Errorf("read a file: %w", slogerr.With(err, "file", p))
+			}
+			after, err := rewriteOutputs(before, p, file.References, opts.From, opts.To)
+			if err != nil {
+				return nil, slogerr.With(err, "file", p) //nolint:wrapcheck
+			}
+			ret.Files = append(ret.Files, &RewrittenFile{
+				Dir:        change.Dir,
+				File:       file.Path,
+				Path:       p,
+				References: file.References,
+				Before:     before,
+				After:      after,
+			})
+		}
+	}
+	return ret, nil
+}
+
+// rewriteOutputs renames outputs of references in a file.
+// A reference ends with the output name, so the token ending at the end of the reference is replaced.
+//
+//	.<from>
+//	["<from>"]
+//
+// hclwrite doesn't have the source range of tokens, but its tokens are same as tokens of hclsyntax.LexConfig,
+// so tokens are found by hclsyntax.LexConfig and replaced by the index.
+func rewriteOutputs(src []byte, filePath string, refs []*Reference, from, to string) ([]byte, error) {
+	nativeTokens, diags := hclsyntax.LexConfig(src, filePath, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	f, diags := hclwrite.ParseConfig(src, filePath, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	tokens := f.BuildTokens(nil)
+	if len(tokens) != len(nativeTokens) {
+		return nil, errors.New("tokens of hclwrite don't match with tokens of hclsyntax")
+	}
+	ends := make(map[hcl.Pos]int, len(nativeTokens))
+	for i, token := range nativeTokens {
+		ends[hcl.Pos{Line: token.Range.End.Line, Column: token.Range.End.Column}] = i
+	}
+	for _, ref := range refs {
+		i, ok := ends[hcl.Pos{Line: ref.Range.EndLine, Column: ref.Range.EndColumn}]
+		if !ok {
+			return nil, slogerr.With(errors.New("the end of the reference isn't found"), "address", ref.Address) //nolint:wrapcheck
+		}
+		if nativeTokens[i].Type == hclsyntax.TokenCBrack {
+			// ["<from>"]
+			i -= 2
+		}
+		if i < 0 || string(tokens[i].Bytes) != from {
+			return nil, slogerr.With(errors.New("the output name of the reference isn't found"), "address", ref.Address) //nolint:wrapcheck
+		}
+		tokens[i].Bytes = []byte(to)
+	}
+	return f.Bytes(), nil
+}
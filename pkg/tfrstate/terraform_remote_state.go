@@ -0,0 +1,367 @@
+package tfrstate
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// extractRemoteStates extracts terraform_remote_state data sources matching with a given backend from a file.
+// terraform_remote_state data sources whose configuration can't be resolved statically are returned as unresolved data sources.
+// Parse failures and data sources without required attributes are returned as diagnostics.
+// moduleDir is the absolute path of the directory where the file is located.
+func extractRemoteStates(logger *slog.Logger, registry *Registry, src []byte, filePath, moduleDir string, backend *Bucket, evalCtx *hcl.EvalContext) ([]*remoteState, []*Unresolved, hcl.Diagnostics) {
+	body, err := parseHCLBody(src, filePath)
+	if err != nil {
+		return nil, nil, toDiagnostics(err, DiagnosticCodeParseError, filePath)
+	}
+	states := []*remoteState{}
+	unresolved := []*Unresolved{}
+	var diags hcl.Diagnostics
+	for _, block := range body.Blocks {
+		instances, err := handleDataBlock(logger, registry, block, moduleDir, evalCtx)
+		if err != nil {
+			if d, ok := missingAttribute(err); ok {
+				diags = diags.Extend(d)
+				continue
+			}
+			unresolved = append(unresolved, newUnresolved(block, filePath, err))
+			continue
+		}
+		if instances == nil {
+			continue
+		}
+		state := &remoteState{
+			Kind: block.Labels[0],
+			Name: block.Labels[1],
+			File: filePath,
+		}
+		matched := false
+		for _, instance := range instances {
+			if !instance.Bucket.Compare(backend) {
+				continue
+			}
+			matched = true
+			if instance.Key == "" {
+				continue
+			}
+			if state.InstanceKeys == nil {
+				state.InstanceKeys = map[string]struct{}{}
+			}
+			state.InstanceKeys[instance.Key] = struct{}{}
+		}
+		if !matched {
+			continue
+		}
+		states = append(states, state)
+	}
+	return states, unresolved, diags
+}
+
+// remoteStateInstance is an instance of terraform_remote_state data source.
+// If the data source has for_each or count, Key is an instance key such as `"api"` and `0`.
+// Otherwise, Key is empty.
+type remoteStateInstance struct {
+	Key    string
+	Bucket *Bucket
+}
+
+// newUnresolved creates an unresolved consumer such as terraform_remote_state data source and Terragrunt dependency.
+// The name of the consumer is the last label of the block.
+// If err is HCL diagnostics, the range of the diagnostic is used as the location.
+func newUnresolved(block *hclsyntax.Block, filePath string, err error) *Unresolved {
+	rng := block.DefRange()
+	reason := err.Error()
+	var diags hcl.Diagnostics
+	if errors.As(err, &diags) && len(diags) > 0 {
+		diag := diags[0]
+		reason = diag.Summary
+		if diag.Detail != "" {
+			reason += ": " + diag.Detail
+		}
+		if diag.Subject != nil {
+			rng = *diag.Subject
+		}
+	}
+	kind := consumerKindDependency
+	if block.Type == "data" {
+		kind = block.Labels[0]
+	}
+	return &Unresolved{
+		File:   filePath,
+		Kind:   kind,
+		Name:   block.Labels[len(block.Labels)-1],
+		Reason: reason,
+		Range:  newRange(rng),
+	}
+}
+
+// newMissingAttribute returns a diagnostic that a required attribute of a block isn't found.
+func newMissingAttribute(block *hclsyntax.Block, name string) hcl.Diagnostics {
+	// e.g. data terraform_remote_state.network, remote_state
+	blockName := block.Type
+	if len(block.Labels) > 0 {
+		blockName += " " + strings.Join(block.Labels, ".")
+	}
+	return hcl.Diagnostics{
+		newDiagnostic(hcl.DiagError, DiagnosticCodeMissingAttribute,
+			"Missing required argument",
+			fmt.Sprintf("The argument %q is required in %s.", name, blockName),
+			block.DefRange().Ptr()),
+	}
+}
+
+// missingAttribute returns diagnostics if err is created by newMissingAttribute.
+func missingAttribute(err error) (hcl.Diagnostics, bool) {
+	var diags hcl.Diagnostics
+	if !errors.As(err, &diags) || len(diags) == 0 || diagnosticCode(diags[0]) != DiagnosticCodeMissingAttribute {
+		return nil, false
+	}
+	return diags, true
+}
+
+func handleDataBlock(logger *slog.Logger, registry *Registry, block *hclsyntax.Block, moduleDir string, evalCtx *hcl.EvalContext) ([]*remoteStateInstance, error) {
+	/*
+		data "terraform_remote_state" "vpc" {
+		  backend = "s3"
+		  config = {
+		    bucket = "terraform-state-XXXXXXXXXXXX"
+		    key    = "production/vpc/terraform.tfstate"
+		  }
+		}
+	*/
+	if block.Type != "data" {
+		return nil, nil
+	}
+	if len(block.Labels) != 2 || (block.Labels[0] != consumerKindRemoteState && block.Labels[0] != consumerKindTFEOutputs) {
+		return nil, nil
+	}
+	logger.Debug("data source is found", "data_source_type", block.Labels[0])
+	if forEach, ok := block.Body.Attributes["for_each"]; ok {
+		return expandForEach(logger, registry, block, moduleDir, forEach.Expr, evalCtx)
+	}
+	if count, ok := block.Body.Attributes["count"]; ok {
+		return expandCount(logger, registry, block, moduleDir, count.Expr, evalCtx)
+	}
+	bucket, err := handleConsumerConfig(logger, registry, block, moduleDir, evalCtx)
+	if err != nil {
+		return nil, err
+	}
+	return []*remoteStateInstance{{Bucket: bucket}}, nil
+}
+
+// expandForEach evaluates a terraform_remote_state data source for each element of for_each.
+//
+//	data "terraform_remote_state" "svc" {
+//	  for_each = toset(local.services)
+//	  backend = "s3"
+//	  config = {
+//	    bucket = "terraform-state"
+//	    key    = "${each.key}/terraform.tfstate"
+//	  }
+//	}
+func expandForEach(logger *slog.Logger, registry *Registry, block *hclsyntax.Block, moduleDir string, expr hcl.Expression, evalCtx *hcl.EvalContext) ([]*remoteStateInstance, error) {
+	val, err := evalValue(expr, evalCtx)
+	if err != nil {
+		return nil, err
+	}
+	ty := val.Type()
+	if val.IsNull() || !(ty.IsMapType() || ty.IsObjectType() || (ty.IsSetType() && ty.ElementType().Equals(cty.String))) {
+		return nil, hcl.Diagnostics{
+			{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid for_each argument",
+				Detail:   "for_each must be a map or a set of strings.",
+				Subject:  expr.Range().Ptr(),
+			},
+		}
+	}
+	instances := make([]*remoteStateInstance, 0, val.LengthInt())
+	for it := val.ElementIterator(); it.Next(); {
+		k, v := it.Element()
+		if ty.IsSetType() {
+			k = v
+		}
+		child := evalCtx.NewChild()
+		child.Variables = map[string]cty.Value{
+			"each": cty.ObjectVal(map[string]cty.Value{
+				"key":   k,
+				"value": v,
+			}),
+		}
+		bucket, err := handleConsumerConfig(logger, registry, block, moduleDir, child)
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, &remoteStateInstance{
+			Key:    instanceKeyString(k),
+			Bucket: bucket,
+		})
+	}
+	return instances, nil
+}
+
+// maxCount is the maximum count which is expanded statically.
+const maxCount = 10000
+
+// expandCount evaluates a terraform_remote_state data source for each index of count.
+func expandCount(logger *slog.Logger, registry *Registry, block *hclsyntax.Block, moduleDir string, expr hcl.Expression, evalCtx *hcl.EvalContext) ([]*remoteStateInstance, error) {
+	val, err := evalValue(expr, evalCtx)
+	if err != nil {
+		return nil, err
+	}
+	invalid := hcl.Diagnostics{
+		{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid count argument",
+			Detail:   "count must be a non-negative whole number.",
+			Subject:  expr.Range().Ptr(),
+		},
+	}
+	num, err := convert.Convert(val, cty.Number)
+	if err != nil || num.IsNull() || !num.IsKnown() {
+		return nil, invalid
+	}
+	n, accuracy := num.AsBigFloat().Int64()
+	if accuracy != big.Exact || n < 0 {
+		return nil, invalid
+	}
+	if n > maxCount {
+		return nil, hcl.Diagnostics{
+			{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid count argument",
+				Detail:   fmt.Sprintf("count is too large to be expanded statically. The maximum is %d.", maxCount),
+				Subject:  expr.Range().Ptr(),
+			},
+		}
+	}
+	instances := make([]*remoteStateInstance, 0, n)
+	for i := range n {
+		index := cty.NumberIntVal(i)
+		child := evalCtx.NewChild()
+		child.Variables = map[string]cty.Value{
+			"count": cty.ObjectVal(map[string]cty.Value{
+				"index": index,
+			}),
+		}
+		bucket, err := handleConsumerConfig(logger, registry, block, moduleDir, child)
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, &remoteStateInstance{
+			Key:    instanceKeyString(index),
+			Bucket: bucket,
+		})
+	}
+	return instances, nil
+}
+
+// instanceKeyString returns an instance key in the HCL syntax such as `"api"` and `0`.
+// If the key is neither a string nor a number, it returns an empty string.
+func instanceKeyString(key cty.Value) string {
+	if !key.IsKnown() || key.IsNull() {
+		return ""
+	}
+	switch {
+	case key.Type().Equals(cty.Number):
+		return key.AsBigFloat().Text('f', -1)
+	case key.Type().Equals(cty.String):
+		return strconv.Quote(key.AsString())
+	}
+	return ""
+}
+
+// handleConsumerConfig evaluates the configuration of a terraform_remote_state or tfe_outputs data source.
+func handleConsumerConfig(logger *slog.Logger, registry *Registry, block *hclsyntax.Block, moduleDir string, evalCtx *hcl.EvalContext) (*Bucket, error) {
+	if block.Labels[0] == consumerKindTFEOutputs {
+		return handleTFEOutputsConfig(logger, registry, block, moduleDir, evalCtx)
+	}
+	return handleRemoteStateConfig(logger, registry, block, moduleDir, evalCtx)
+}
+
+// handleTFEOutputsConfig evaluates organization and workspace attributes of a tfe_outputs data source.
+// tfe_outputs reads the state of a HCP Terraform or Terraform Enterprise workspace,
+// so it's compared with the remote backend and the cloud block.
+//
+//	data "tfe_outputs" "vpc" {
+//	  organization = "my-org"
+//	  workspace    = "vpc"
+//	}
+func handleTFEOutputsConfig(logger *slog.Logger, registry *Registry, block *hclsyntax.Block, moduleDir string, evalCtx *hcl.EvalContext) (*Bucket, error) {
+	values := make(map[string]cty.Value, 2) //nolint:mnd
+	for _, name := range []string{"organization", "workspace"} {
+		values[name] = cty.NullVal(cty.String)
+		attr, ok := block.Body.Attributes[name]
+		if !ok {
+			continue
+		}
+		val, err := evalValue(attr.Expr, evalCtx)
+		if err != nil {
+			return nil, err
+		}
+		values[name] = val
+	}
+	bucket, err := registry.Bucket(BackendTypeRemote, map[string]cty.Value{
+		"organization": values["organization"],
+		"workspaces": cty.ObjectVal(map[string]cty.Value{
+			"name": values["workspace"],
+		}),
+	}, moduleDir)
+	if err != nil {
+		return nil, fmt.Errorf("get the backend configuration: %w", err)
+	}
+	logger.Debug("normalized backend configuration of tfe_outputs", append(bucket.LogAttrs(), "data_source", block.Labels[1])...)
+	return bucket, nil
+}
+
+// handleRemoteStateConfig evaluates backend and config attributes of a terraform_remote_state data source.
+// If the backend type isn't supported, the returned Bucket has only the type so that it doesn't match with any backend.
+func handleRemoteStateConfig(logger *slog.Logger, registry *Registry, block *hclsyntax.Block, moduleDir string, evalCtx *hcl.EvalContext) (*Bucket, error) {
+	backendAttr, ok := block.Body.Attributes["backend"]
+	if !ok {
+		return nil, newMissingAttribute(block, "backend")
+	}
+	backendType, err := evalString(backendAttr.Expr, evalCtx)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := registry.Get(backendType); !ok {
+		return &Bucket{Type: backendType}, nil
+	}
+	configAttr, ok := block.Body.Attributes["config"]
+	if !ok {
+		return nil, newMissingAttribute(block, "config")
+	}
+	logger.Debug("config attribute is found")
+
+	configVal, err := evalValue(configAttr.Expr, evalCtx)
+	if err != nil {
+		return nil, err
+	}
+	ty := configVal.Type()
+	if configVal.IsNull() || !(ty.IsObjectType() || ty.IsMapType()) {
+		return nil, hcl.Diagnostics{
+			{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid config argument",
+				Detail:   "config must be an object.",
+				Subject:  configAttr.Expr.Range().Ptr(),
+			},
+		}
+	}
+	bucket, err := registry.Bucket(backendType, configVal.AsValueMap(), moduleDir)
+	if err != nil {
+		return nil, fmt.Errorf("get the backend configuration: %w", err)
+	}
+	logger.Debug("normalized backend configuration of terraform_remote_state", append(bucket.LogAttrs(), "data_source", block.Labels[1])...)
+	return bucket, nil
+}
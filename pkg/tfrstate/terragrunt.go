@@ -0,0 +1,346 @@
+package tfrstate
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+const terragruntFileName = "terragrunt.hcl"
+
+func isTerragruntFile(path string) bool {
+	return filepath.Base(path) == terragruntFileName
+}
+
+// parseHCLBody parses a HCL file.
+func parseHCLBody(src []byte, filePath string) (*hclsyntax.Body, error) {
+	file, diags := hclsyntax.ParseConfig(src, filePath, hcl.Pos{Byte: 0, Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, errors.New("convert file body to body type")
+	}
+	return body, nil
+}
+
+// findTerragruntBackend finds the remote_state block in terragrunt.hcl in dir or files included by terragrunt.hcl.
+// If no remote_state block is found, the backend type is empty.
+// Files which can't be parsed and invalid remote_state blocks are returned as diagnostics.
+//
+//	include "root" {
+//	  path = find_in_parent_folders("root.hcl")
+//	}
+func findTerragruntBackend(logger *slog.Logger, afs afero.Fs, dir string) (string, map[string]cty.Value, hcl.Diagnostics, error) {
+	p := filepath.Join(dir, terragruntFileName)
+	if f, err := afero.Exists(afs, p); err != nil {
+		return "", nil, nil, fmt.Errorf("check if terragrunt.hcl exists: %w", slogerr.With(err, "file", p))
+	} else if !f {
+		return "", nil, nil, nil
+	}
+	body, diags, err := readTerragruntBody(afs, p)
+	if err != nil || body == nil {
+		return "", nil, diags, err
+	}
+	evalCtx := newTerragruntEvalContext(logger, afs, body, dir, dir)
+	backendType, config, ds := terragruntRemoteState(logger, body, evalCtx)
+	diags = diags.Extend(ds)
+	if backendType != "" || diags.HasErrors() {
+		return backendType, config, diags, nil
+	}
+	for _, block := range body.Blocks {
+		if block.Type != "include" {
+			continue
+		}
+		attr, ok := block.Body.Attributes["path"]
+		if !ok {
+			continue
+		}
+		includePath, err := evalString(attr.Expr, evalCtx)
+		if err != nil {
+			return "", nil, diags.Append(newDiagnostic(hcl.DiagError, DiagnosticCodeInvalidBackend,
+				"Invalid include path",
+				"The path of include can't be evaluated, so the remote_state block can't be read: "+err.Error(),
+				attr.Expr.Range().Ptr())), nil
+		}
+		includePath = absPath(dir, includePath)
+		parent, ds, err := readTerragruntBody(afs, includePath)
+		diags = diags.Extend(ds)
+		if err != nil || parent == nil {
+			return "", nil, diags, err
+		}
+		parentCtx := newTerragruntEvalContext(logger, afs, parent, dir, filepath.Dir(includePath))
+		backendType, config, ds := terragruntRemoteState(logger, parent, parentCtx)
+		diags = diags.Extend(ds)
+		if backendType != "" || ds.HasErrors() {
+			return backendType, config, diags, nil
+		}
+	}
+	return "", nil, diags, nil
+}
+
+// readTerragruntBody reads and parses a Terragrunt configuration file.
+// If the file can't be parsed, it returns diagnostics.
+func readTerragruntBody(afs afero.Fs, path string) (*hclsyntax.Body, hcl.Diagnostics, error) {
+	src, err := afero.ReadFile(afs, path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read a file: %w", slogerr.With(err, "file", path))
+	}
+	body, err := parseHCLBody(src, path)
+	if err != nil {
+		return nil, toDiagnostics(err, DiagnosticCodeParseError, path), nil
+	}
+	return body, nil, nil
+}
+
+func readHCLBody(afs afero.Fs, path string) (*hclsyntax.Body, error) {
+	src, err := afero.ReadFile(afs, path)
+	if err != nil {
+		return nil, fmt.Errorf("read a file: %w", slogerr.With(err, "file", path))
+	}
+	return parseHCLBody(src, path)
+}
+
+// terragruntRemoteState returns the backend type and the configuration of a remote_state block.
+// Attributes of the configuration which can't be resolved are unknown.
+// If the backend type can't be resolved, it returns diagnostics.
+//
+//	remote_state {
+//	  backend = "s3"
+//	  config = {
+//	    bucket = "my-terraform-state"
+//	    key    = "${path_relative_to_include()}/terraform.tfstate"
+//	  }
+//	}
+func terragruntRemoteState(logger *slog.Logger, body *hclsyntax.Body, evalCtx *hcl.EvalContext) (string, map[string]cty.Value, hcl.Diagnostics) {
+	for _, block := range body.Blocks {
+		if block.Type != "remote_state" {
+			continue
+		}
+		backendAttr, ok := block.Body.Attributes["backend"]
+		if !ok {
+			return "", nil, newMissingAttribute(block, "backend")
+		}
+		backendType, err := evalString(backendAttr.Expr, evalCtx)
+		if err != nil {
+			return "", nil, hcl.Diagnostics{newDiagnostic(hcl.DiagError, DiagnosticCodeInvalidBackend,
+				"Invalid backend type",
+				"The backend type of remote_state can't be resolved statically: "+err.Error(),
+				backendAttr.Expr.Range().Ptr())}
+		}
+		configAttr, ok := block.Body.Attributes["config"]
+		if !ok {
+			return backendType, map[string]cty.Value{}, nil
+		}
+		return backendType, evalObjectAttributes(logger, configAttr.Expr, evalCtx), nil
+	}
+	return "", nil, nil
+}
+
+// evalObjectAttributes evaluates each attribute of an object expression.
+// Attributes which can't be resolved are unknown.
+func evalObjectAttributes(logger *slog.Logger, expr hclsyntax.Expression, evalCtx *hcl.EvalContext) map[string]cty.Value {
+	config := map[string]cty.Value{}
+	obj, ok := expr.(*hclsyntax.ObjectConsExpr)
+	if !ok {
+		val, err := evalValue(expr, evalCtx)
+		if err != nil || val.IsNull() || !(val.Type().IsObjectType() || val.Type().IsMapType()) {
+			return config
+		}
+		return val.AsValueMap()
+	}
+	for _, item := range obj.Items {
+		name, err := evalString(item.KeyExpr, evalCtx)
+		if err != nil {
+			continue
+		}
+		val, err := evalValue(item.ValueExpr, evalCtx)
+		if err != nil {
+			slogerr.WithError(logger, err).Debug("evaluate an attribute of remote_state", "attr", name)
+			config[name] = cty.DynamicVal
+			continue
+		}
+		config[name] = val
+	}
+	return config
+}
+
+// newTerragruntEvalContext creates an evaluation context of a Terragrunt configuration file.
+// childDir is the directory of terragrunt.hcl being processed and includeDir is the directory of the file being evaluated.
+// If the file isn't included, includeDir equals childDir.
+// The context has local values (local.*) and a subset of Terragrunt built-in functions.
+func newTerragruntEvalContext(logger *slog.Logger, afs afero.Fs, body *hclsyntax.Body, childDir, includeDir string) *hcl.EvalContext {
+	funcs := functions()
+	for name, fn := range terragruntFunctions(logger, afs, childDir, includeDir) {
+		funcs[name] = fn
+	}
+	evalCtx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{},
+		Functions: funcs,
+	}
+	locals := map[string]hcl.Expression{}
+	for _, block := range body.Blocks {
+		if block.Type != "locals" {
+			continue
+		}
+		for name, attr := range block.Body.Attributes {
+			locals[name] = attr.Expr
+		}
+	}
+	evalCtx.Variables["local"] = evalLocals(evalCtx, locals)
+	return evalCtx
+}
+
+// terragruntFunctions returns Terragrunt built-in functions which are often used to build remote_state and dependency blocks.
+// get_env returns the default value because environment variables can't be resolved statically.
+func terragruntFunctions(logger *slog.Logger, afs afero.Fs, childDir, includeDir string) map[string]function.Function {
+	relToInclude, err := filepath.Rel(includeDir, childDir)
+	if err != nil {
+		slogerr.WithError(logger, err).Debug("get a relative path from the included file")
+		relToInclude = "."
+	}
+	relFromInclude, err := filepath.Rel(childDir, includeDir)
+	if err != nil {
+		slogerr.WithError(logger, err).Debug("get a relative path to the included file")
+		relFromInclude = "."
+	}
+	return map[string]function.Function{
+		"find_in_parent_folders":     findInParentFoldersFunc(afs, childDir),
+		"get_env":                    getEnvFunc(),
+		"get_parent_terragrunt_dir":  stringConstFunc(includeDir),
+		"get_terragrunt_dir":         stringConstFunc(childDir),
+		"path_relative_from_include": stringConstFunc(filepath.ToSlash(relFromInclude)),
+		"path_relative_to_include":   stringConstFunc(filepath.ToSlash(relToInclude)),
+	}
+}
+
+// stringConstFunc returns a function returning a constant string.
+// Arguments such as the name of include are ignored.
+func stringConstFunc(s string) function.Function {
+	return function.New(&function.Spec{
+		VarParam: &function.Parameter{
+			Name: "args",
+			Type: cty.String,
+		},
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(_ []cty.Value, _ cty.Type) (cty.Value, error) {
+			return cty.StringVal(s), nil
+		},
+	})
+}
+
+// findInParentFoldersFunc returns find_in_parent_folders.
+// It searches a file from the parent directory of dir to the root directory.
+//
+//	find_in_parent_folders()
+//	find_in_parent_folders("root.hcl")
+//	find_in_parent_folders("root.hcl", "fallback.hcl")
+func findInParentFoldersFunc(afs afero.Fs, dir string) function.Function {
+	return function.New(&function.Spec{
+		VarParam: &function.Parameter{
+			Name: "args",
+			Type: cty.String,
+		},
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, _ cty.Type) (cty.Value, error) {
+			name := terragruntFileName
+			if len(args) > 0 {
+				name = args[0].AsString()
+			}
+			for d := filepath.Dir(dir); ; d = filepath.Dir(d) {
+				p := filepath.Join(d, name)
+				if f, err := afero.Exists(afs, p); err == nil && f {
+					return cty.StringVal(p), nil
+				}
+				if d == filepath.Dir(d) {
+					break
+				}
+			}
+			if len(args) > 1 {
+				return args[1], nil
+			}
+			return cty.NilVal, slogerr.With(errors.New("a file isn't found in parent folders"), "name", name) //nolint:wrapcheck
+		},
+	})
+}
+
+// getEnvFunc returns get_env.
+// If the default value isn't given, the result is unknown.
+func getEnvFunc() function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name: "name",
+				Type: cty.String,
+			},
+		},
+		VarParam: &function.Parameter{
+			Name: "default",
+			Type: cty.String,
+		},
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, _ cty.Type) (cty.Value, error) {
+			if len(args) > 1 {
+				return args[1], nil
+			}
+			return cty.UnknownVal(cty.String), nil
+		},
+	})
+}
+
+// extractDependencies extracts Terragrunt dependency blocks referring the Terraform Root Module with a given backend from terragrunt.hcl.
+// The backend of the dependency is resolved from config_path by resolveBackend.
+// dependency blocks whose config_path can't be resolved statically are returned as unresolved dependencies.
+// Parse failures and dependency blocks without config_path are returned as diagnostics.
+//
+//	dependency "vpc" {
+//	  config_path = "../vpc"
+//	}
+func extractDependencies(logger *slog.Logger, afs afero.Fs, file *tfFile, moduleDir string, backend *Bucket, resolveBackend func(dir string) (*Bucket, error)) ([]*remoteState, []*Unresolved, hcl.Diagnostics) {
+	body, err := parseHCLBody(file.Byte, file.Path)
+	if err != nil {
+		return nil, nil, toDiagnostics(err, DiagnosticCodeParseError, file.Path)
+	}
+	var diags hcl.Diagnostics
+	evalCtx := newTerragruntEvalContext(logger, afs, body, moduleDir, moduleDir)
+	states := []*remoteState{}
+	unresolved := []*Unresolved{}
+	for _, block := range body.Blocks {
+		if block.Type != "dependency" || len(block.Labels) != 1 {
+			continue
+		}
+		attr, ok := block.Body.Attributes["config_path"]
+		if !ok {
+			diags = diags.Extend(newMissingAttribute(block, "config_path"))
+			continue
+		}
+		configPath, err := evalString(attr.Expr, evalCtx)
+		if err != nil {
+			unresolved = append(unresolved, newUnresolved(block, file.Path, err))
+			continue
+		}
+		bucket, err := resolveBackend(absPath(moduleDir, configPath))
+		if err != nil {
+			unresolved = append(unresolved, newUnresolved(block, file.Path, err))
+			continue
+		}
+		if bucket == nil || !bucket.Compare(backend) {
+			continue
+		}
+		states = append(states, &remoteState{
+			Kind: consumerKindDependency,
+			Name: block.Labels[0],
+			File: file.Path,
+		})
+	}
+	return states, unresolved, diags
+}
@@ -0,0 +1,403 @@
+// Package tfrstate finds Terraform Root Modules depending on a given Terraform State via terraform_remote_state, tfe_outputs, and Terragrunt dependencies.
+package tfrstate
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+)
+
+// Options is options of Find.
+type Options struct {
+	// WorkDir is the absolute path of the working directory.
+	// Relative paths in Options are relative to WorkDir.
+	WorkDir string
+	// BaseDir is the directory where consumers are searched.
+	BaseDir string
+	// Backend is the backend of the given Terraform State.
+	// If Backend is nil, the backend is read from BackendDir.
+	Backend *Bucket
+	// BackendDir is the directory of the Terraform Root Module of the given Terraform State.
+	BackendDir string
+	// BackendConfigs are partial backend configurations like terraform init -backend-config.
+	// Each configuration is either a file path or a pair of key and value separated by "=".
+	BackendConfigs []string
+	// InitState decides how .terraform/terraform.tfstate created by terraform init is used to get the backend.
+	// One of InitStateFallback, InitStatePrefer, and InitStateIgnore.
+	// If InitState is empty, InitStateFallback is used.
+	InitState string
+	// ChangedOutputs is a map of changed output names and their change kinds such as ChangeKindRemoved.
+	// The change kind can be empty if it's unknown.
+	// If ChangedOutputs is empty, references to any outputs are returned.
+	ChangedOutputs map[string]string
+	// RenamedOutputs is a map of old output names and new output names.
+	// References to old outputs whose change kind is ChangeKindRenamed have new output names and suggested references.
+	RenamedOutputs map[string]string
+	// Vars are variables used to resolve configurations of consumers and backends.
+	Vars map[string]string
+	// VarFiles are variable definitions files.
+	// Vars take precedence over VarFiles.
+	VarFiles []string
+	// Registry is a set of supported backend types.
+	// If Registry is nil, built-in backend types are supported.
+	Registry *Registry
+	// OnReferences is called for each file as soon as references are found.
+	// dir is a relative path from BaseDir and file is a relative path from dir.
+	// OnReferences is optional.
+	OnReferences func(dir, file string, refs []*Reference) error
+}
+
+// Result is the result of Find.
+type Result struct {
+	// Backend is the normalized backend of the given Terraform State.
+	// If the backend isn't found, Backend is nil and the other fields except for Diagnostics are empty.
+	Backend *Bucket   `json:"backend"`
+	Changes []*Change `json:"changes"`
+	// Unresolved is a list of consumers whose configuration can't be resolved statically.
+	Unresolved []*Unresolved `json:"unresolved"`
+	// Diagnostics is a list of problems such as files which can't be parsed.
+	Diagnostics []*Diagnostic `json:"diagnostics"`
+	// BaseDir is the absolute path of the base directory.
+	BaseDir string `json:"base_dir"`
+}
+
+type Change struct {
+	Dir   string         `json:"dir"`
+	Files []*ChangedFile `json:"files"`
+}
+
+type ChangedFile struct {
+	Path       string       `json:"path"`
+	Outputs    []string     `json:"outputs"`
+	References []*Reference `json:"references"`
+}
+
+// Unresolved is a consumer such as terraform_remote_state data source whose configuration can't be resolved statically.
+// For example, a configuration referring variables can't be resolved.
+type Unresolved struct {
+	// Dir is a relative path from the base directory
+	Dir string `json:"dir"`
+	// File is a relative path from Dir
+	File string `json:"file"`
+	// Kind is the kind of the consumer such as "terraform_remote_state", "tfe_outputs", and "dependency".
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+	Range  *Range `json:"range"`
+}
+
+// Address returns the address of the consumer such as "data.terraform_remote_state.vpc" and "dependency.vpc".
+func (u *Unresolved) Address() string {
+	for _, kind := range consumerKinds() {
+		if kind.Name == u.Kind {
+			return kind.address(u.Name)
+		}
+	}
+	return u.Name
+}
+
+// remoteState is a consumer such as terraform_remote_state data source referring the given Terraform State.
+type remoteState struct {
+	// Kind is the kind of the consumer such as "terraform_remote_state" and "dependency".
+	Kind string
+	Name string
+	File string
+	// InstanceKeys are keys of instances matching with the backend if the data source has for_each or count.
+	// Otherwise, InstanceKeys is nil.
+	InstanceKeys map[string]struct{}
+}
+
+// tfFile is a file which may include consumers.
+type tfFile struct {
+	Path    string
+	Content string
+	Byte    []byte
+}
+
+// consumerDir is a directory including consumers.
+type consumerDir struct {
+	Path   string
+	Files  []*tfFile
+	States []*remoteState
+}
+
+// Find finds consumers referring outputs of the given Terraform State.
+func Find(_ context.Context, logger *slog.Logger, afs afero.Fs, opts *Options) (*Result, error) { //nolint:funlen,cyclop
+	if err := validateInitState(opts.InitState); err != nil {
+		return nil, err
+	}
+	registry := opts.Registry
+	if registry == nil {
+		registry = NewRegistry()
+	}
+	result := &Result{
+		Backend:     opts.Backend,
+		Changes:     []*Change{},
+		Unresolved:  []*Unresolved{},
+		Diagnostics: []*Diagnostic{},
+		BaseDir:     absPath(opts.WorkDir, opts.BaseDir),
+	}
+	var diags hcl.Diagnostics
+	defer func() {
+		result.Diagnostics = newDiagnostics(opts.WorkDir, opts.BaseDir, diags)
+	}()
+
+	cliVars, err := parseCLIVars(afs, opts.WorkDir, opts.VarFiles, opts.Vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Backend == nil {
+		// parse HCLs in dir and extract backend configurations
+		b, ds, err := findBackendConfig(logger, afs, registry, absPath(opts.WorkDir, opts.BackendDir), cliVars, absBackendConfigs(opts.WorkDir, opts.BackendConfigs), opts.InitState)
+		diags = diags.Extend(ds)
+		if err != nil {
+			return nil, err
+		}
+		if b == nil {
+			return result, nil
+		}
+		result.Backend = b
+	}
+	bucket := result.Backend
+	logger.Debug("normalized backend configuration", bucket.LogAttrs()...)
+
+	// find HCLs in base directories and list directories where changed outputs are used
+	tfFiles, err := findTFFiles(afs, result.BaseDir)
+	if err != nil {
+		return nil, err
+	}
+	logger.Debug("Found *.tf files", "num_of_files", len(tfFiles))
+	dirs := map[string]*consumerDir{}
+	// Find files including a string "terraform_remote_state" and terragrunt.hcl including a string "dependency"
+	if err := filterFilesWithRemoteState(afs, tfFiles, dirs); err != nil {
+		return nil, err
+	}
+
+	// backends of Terraform Root Modules referred by Terragrunt dependencies
+	backends := map[string]*Bucket{}
+	resolveBackend := func(dir string) (*Bucket, error) {
+		if b, ok := backends[dir]; ok {
+			return b, nil
+		}
+		b, ds, err := findBackendConfig(logger, afs, registry, dir, cliVars, nil, opts.InitState)
+		diags = diags.Extend(ds)
+		if err != nil {
+			return nil, err
+		}
+		backends[dir] = b
+		return b, nil
+	}
+
+	// Find terraform_remote_state data sources and Terragrunt dependencies.
+	for _, dirPath := range slices.Sorted(maps.Keys(dirs)) {
+		dir := dirs[dirPath]
+		evalCtx, err := newEvalContext(logger, afs, dir.Path, cliVars)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range dir.Files {
+			logger := logger.With("file", file.Path)
+			var remoteStates []*remoteState
+			var us []*Unresolved
+			var ds hcl.Diagnostics
+			if isTerragruntFile(file.Path) {
+				logger.Debug("terragrunt.hcl is found")
+				remoteStates, us, ds = extractDependencies(logger, afs, file, absPath(opts.WorkDir, dir.Path), bucket, resolveBackend)
+			} else {
+				logger.Debug("terraform_remote_state is found")
+				remoteStates, us, ds = extractRemoteStates(logger, registry, file.Byte, file.Path, absPath(opts.WorkDir, dir.Path), bucket, evalCtx)
+			}
+			diags = diags.Extend(ds)
+			dir.States = append(dir.States, remoteStates...)
+			for _, u := range us {
+				logger.Warn("consumer can't be resolved", "consumer", u.Address(), "reason", u.Reason)
+				relDir, relFile, err := relPaths(opts.WorkDir, opts.BaseDir, dir.Path, file.Path)
+				if err != nil {
+					return nil, err
+				}
+				u.Dir = relDir
+				u.File = relFile
+				result.Unresolved = append(result.Unresolved, u)
+			}
+		}
+	}
+
+	// Find attributes where changed outputs are used
+	// data.terraform_remote_state.<name>.outputs.<output_name>
+	// directory -> file -> references
+	changed := map[string]map[string][]*Reference{}
+	if err := findCaller(logger, dirs, opts.ChangedOutputs, func(dir *consumerDir, file *tfFile, refs []*Reference) error {
+		suggestRenames(refs, opts.RenamedOutputs)
+		m, ok := changed[dir.Path]
+		if !ok {
+			m = map[string][]*Reference{}
+		}
+		m[file.Path] = append(m[file.Path], refs...)
+		changed[dir.Path] = m
+		if opts.OnReferences == nil {
+			return nil
+		}
+		// Notify references as soon as they are found
+		dirPath, filePath, err := relPaths(opts.WorkDir, opts.BaseDir, dir.Path, file.Path)
+		if err != nil {
+			return err
+		}
+		return opts.OnReferences(dirPath, filePath, refs)
+	}); err != nil {
+		return nil, err
+	}
+	// Format the result to output as JSON
+	changes, err := toChanges(opts.WorkDir, opts.BaseDir, len(opts.ChangedOutputs) != 0, changed)
+	if err != nil {
+		return nil, err
+	}
+	result.Changes = changes
+	return result, nil
+}
+
+// suggestRenames sets new output names and suggested references to references to renamed outputs.
+func suggestRenames(refs []*Reference, renamed map[string]string) {
+	for _, ref := range refs {
+		if ref.Change != ChangeKindRenamed {
+			continue
+		}
+		to, ok := renamed[ref.Output]
+		if !ok {
+			continue
+		}
+		ref.RenamedTo = to
+		// The address ends with the output name.
+		ref.Suggestion = strings.TrimSuffix(ref.Address, ref.Output) + to
+	}
+}
+
+// relPaths converts dir to the relative path from the base directory and file to the relative path from dir.
+// baseDir, dir, and file are absolute paths or relative paths from the current directory.
+func relPaths(pwd, baseDir, dir, file string) (string, string, error) {
+	absDir := absPath(pwd, dir)
+	relDir, err := filepath.Rel(absPath(pwd, baseDir), absDir)
+	if err != nil {
+		return "", "", fmt.Errorf("get a relative path from baseDir to dir: %w", err)
+	}
+	relFile, err := filepath.Rel(absDir, absPath(pwd, file))
+	if err != nil {
+		return "", "", fmt.Errorf("get a relative path from dir to file: %w", err)
+	}
+	return relDir, relFile, nil
+}
+
+func toChanges(pwd, baseDir string, hasChangedOutputs bool, changed map[string]map[string][]*Reference) ([]*Change, error) {
+	changes := make([]*Change, 0, len(changed))
+	// baseDir is an absolute path or a relative path from the current directory
+	baseDir = absPath(pwd, baseDir)
+	for dir, m := range changed {
+		// convert dir to the relative path from the base directory
+		// dir is an absolute path or a relative path from the current directory
+		absDir := dir
+		if !filepath.IsAbs(dir) {
+			absDir = filepath.Join(pwd, dir)
+		}
+		dir, err := filepath.Rel(baseDir, absDir)
+		if err != nil {
+			return nil, fmt.Errorf("get a relative path from baseDir to dir: %w", err)
+		}
+		files := make([]*ChangedFile, 0, len(m))
+		for file, refs := range m {
+			// convert file to the relative path from dir
+			// file is an absolute path or a relative path from the current directory
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(pwd, file)
+			}
+			file, err := filepath.Rel(absDir, file)
+			if err != nil {
+				return nil, fmt.Errorf("get a relative path from baseDir to file: %w", err)
+			}
+			outputs := map[string]struct{}{}
+			if hasChangedOutputs {
+				for _, ref := range refs {
+					outputs[ref.Output] = struct{}{}
+				}
+			}
+			files = append(files, &ChangedFile{
+				Path:       file,
+				Outputs:    slices.Sorted(maps.Keys(outputs)),
+				References: refs,
+			})
+		}
+		slices.SortFunc(files, func(a, b *ChangedFile) int {
+			return strings.Compare(a.Path, b.Path)
+		})
+		changes = append(changes, &Change{
+			Dir:   dir,
+			Files: files,
+		})
+	}
+	slices.SortFunc(changes, func(a, b *Change) int {
+		return strings.Compare(a.Dir, b.Dir)
+	})
+	return changes, nil
+}
+
+// absBackendConfigs converts relative paths of backend configuration files to absolute paths from pwd.
+// Pairs of key and value are kept as they are.
+func absBackendConfigs(pwd string, backendConfigs []string) []string {
+	ret := make([]string, len(backendConfigs))
+	for i, backendConfig := range backendConfigs {
+		if strings.Contains(backendConfig, "=") {
+			ret[i] = backendConfig
+			continue
+		}
+		ret[i] = absPath(pwd, backendConfig)
+	}
+	return ret
+}
+
+// absPath returns an absolute path of p.
+// p is an absolute path or a relative path from pwd.
+func absPath(pwd, p string) string {
+	if filepath.IsAbs(p) {
+		return filepath.Clean(p)
+	}
+	return filepath.Join(pwd, p)
+}
+
+func filterFilesWithRemoteState(afs afero.Fs, tfFiles []string, dirs map[string]*consumerDir) error {
+	for _, matchFile := range tfFiles {
+		// Find files including a string "terraform_remote_state"
+		b, err := afero.ReadFile(afs, matchFile)
+		if err != nil {
+			return fmt.Errorf("read a file: %w", slogerr.With(err, "file", matchFile))
+		}
+		s := string(b)
+		if isTerragruntFile(matchFile) {
+			if !strings.Contains(s, "dependency") {
+				continue
+			}
+		} else if !strings.Contains(s, "terraform_remote_state") && !strings.Contains(s, "tfe_outputs") {
+			continue
+		}
+		dirPath := filepath.Dir(matchFile)
+		dir, ok := dirs[dirPath]
+		if !ok {
+			dir = &consumerDir{
+				Path: dirPath,
+			}
+		}
+		dir.Files = append(dir.Files, &tfFile{
+			Path:    matchFile,
+			Content: s,
+			Byte:    b,
+		})
+		dirs[dirPath] = dir
+	}
+	return nil
+}
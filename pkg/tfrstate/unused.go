@@ -0,0 +1,387 @@
+package tfrstate
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/slog-error/slogerr"
+)
+
+// UnusedOptions is options of FindUnused.
+type UnusedOptions struct {
+	// WorkDir is the absolute path of the working directory.
+	// Relative paths in UnusedOptions are relative to WorkDir.
+	WorkDir string
+	// BaseDir is the directory where producers and consumers are searched.
+	BaseDir string
+	// Vars are variables used to resolve configurations of consumers and backends.
+	Vars map[string]string
+	// VarFiles are variable definitions files.
+	// Vars take precedence over VarFiles.
+	VarFiles []string
+	// InitState decides how .terraform/terraform.tfstate is used to get backends of producers.
+	// If InitState is empty, InitStateFallback is used.
+	InitState string
+	// Registry is a set of supported backend types.
+	// If Registry is nil, built-in backend types are supported.
+	Registry *Registry
+}
+
+// UnusedResult is the result of FindUnused.
+type UnusedResult struct {
+	// DataSources are terraform_remote_state and tfe_outputs data sources which aren't referred in their directory.
+	DataSources []*UnusedDataSource `json:"data_sources"`
+	// Outputs are outputs of producers in the base directory which no consumer refers.
+	Outputs []*UnusedOutput `json:"outputs"`
+	// Unresolved is a list of consumers whose configuration can't be resolved statically.
+	// Outputs referred by them are treated as used in all producers.
+	// If they refer outputs with a dynamic key or the whole outputs, all outputs of all producers are treated as used.
+	Unresolved  []*Unresolved `json:"unresolved"`
+	Diagnostics []*Diagnostic `json:"diagnostics"`
+	// BaseDir is the absolute path of the base directory.
+	BaseDir string `json:"base_dir"`
+}
+
+// UnusedDataSource is a data source which isn't referred in its directory.
+type UnusedDataSource struct {
+	// Dir is a relative path from the base directory
+	Dir string `json:"dir"`
+	// File is a relative path from Dir
+	File string `json:"file"`
+	// Kind is the kind of the data source such as "terraform_remote_state".
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+	Range *Range `json:"range"`
+}
+
+// UnusedOutput is an output of a producer which no consumer refers.
+type UnusedOutput struct {
+	// Dir is a relative path from the base directory
+	Dir string `json:"dir"`
+	// File is a relative path from Dir
+	File  string `json:"file"`
+	Name  string `json:"name"`
+	Range *Range `json:"range"`
+}
+
+// consumerUsage is how a consumer is used in a directory.
+type consumerUsage struct {
+	// Outputs are names of referred outputs.
+	Outputs map[string]struct{}
+	// All is true if the consumer is used in a way that any output may be referred,
+	// such as outputs[var.name] and passing outputs to a module.
+	All bool
+}
+
+// consumerDecl is a consumer declared in a directory.
+type consumerDecl struct {
+	Kind  string
+	Name  string
+	File  string
+	Range hcl.Range
+	// Buckets are backends of instances. They're empty if the backend can't be resolved statically.
+	Buckets []*Bucket
+	// Dir is the absolute path of the Terraform Root Module referred by a Terragrunt dependency.
+	Dir string
+}
+
+// FindUnused finds data sources which aren't referred in their directory and outputs of producers which no consumer refers.
+// Producers are Terraform Root Modules in the base directory.
+// References are found in the same way as Find.
+func FindUnused(_ context.Context, logger *slog.Logger, afs afero.Fs, opts *UnusedOptions) (*UnusedResult, error) { //nolint:cyclop,funlen
+	if err := validateInitState(opts.InitState); err != nil {
+		return nil, err
+	}
+	registry := opts.Registry
+	if registry == nil {
+		registry = NewRegistry()
+	}
+	baseDir := absPath(opts.WorkDir, opts.BaseDir)
+	cliVars, err := parseCLIVars(afs, opts.WorkDir, opts.VarFiles, opts.Vars)
+	if err != nil {
+		return nil, err
+	}
+	tfFiles, err := findTFFiles(afs, baseDir)
+	if err != nil {
+		return nil, err
+	}
+	result := &UnusedResult{
+		DataSources: []*UnusedDataSource{},
+		Outputs:     []*UnusedOutput{},
+		Unresolved:  []*Unresolved{},
+		BaseDir:     baseDir,
+	}
+	producers, diags, err := findProducers(logger, afs, registry, opts.WorkDir, baseDir, tfFiles, cliVars, opts.InitState)
+	if err != nil {
+		return nil, err
+	}
+
+	// outputs referred by consumers for each producer directory
+	used := make(map[string]*consumerUsage, len(producers))
+	for _, p := range producers {
+		used[p.Dir] = &consumerUsage{Outputs: map[string]struct{}{}}
+	}
+	// outputs referred by consumers whose producer can't be resolved
+	usedByUnresolved := map[string]struct{}{}
+
+	dirs := map[string]*consumerDir{}
+	if err := filterFilesWithRemoteState(afs, tfFiles, dirs); err != nil {
+		return nil, err
+	}
+	for _, dirPath := range slices.Sorted(maps.Keys(dirs)) {
+		dir := dirs[dirPath]
+		moduleDir := absPath(opts.WorkDir, dir.Path)
+		evalCtx, err := newEvalContext(logger, afs, dir.Path, cliVars)
+		if err != nil {
+			return nil, err
+		}
+		var decls []*consumerDecl
+		usages := map[string]*consumerUsage{}
+		for _, file := range dir.Files {
+			body, err := parseHCLBody(file.Byte, file.Path)
+			if err != nil {
+				diags = diags.Extend(toDiagnostics(err, DiagnosticCodeParseError, file.Path))
+				continue
+			}
+			ds, us := findConsumerDecls(logger, afs, registry, body, file, moduleDir, evalCtx)
+			decls = append(decls, ds...)
+			for _, u := range us {
+				relDir, relFile, err := relPaths(opts.WorkDir, opts.BaseDir, dir.Path, file.Path)
+				if err != nil {
+					return nil, err
+				}
+				u.Dir = relDir
+				u.File = relFile
+				result.Unresolved = append(result.Unresolved, u)
+			}
+			findUsages(body, file.Byte, usages)
+		}
+		for _, decl := range decls {
+			usage, ok := usages[decl.Kind+"."+decl.Name]
+			if !ok {
+				if decl.Kind == consumerKindDependency {
+					// dependency blocks are also used to order Terragrunt modules
+					continue
+				}
+				relDir, relFile, err := relPaths(opts.WorkDir, opts.BaseDir, dir.Path, decl.File)
+				if err != nil {
+					return nil, err
+				}
+				result.DataSources = append(result.DataSources, &UnusedDataSource{
+					Dir:   relDir,
+					File:  relFile,
+					Kind:  decl.Kind,
+					Name:  decl.Name,
+					Range: newRange(decl.Range),
+				})
+				continue
+			}
+			if decl.Buckets == nil && decl.Dir == "" {
+				// The consumer may refer any producer
+				for _, u := range used {
+					u.All = u.All || usage.All
+				}
+				maps.Copy(usedByUnresolved, usage.Outputs)
+				continue
+			}
+			for _, p := range producers {
+				if !decl.refers(p) {
+					continue
+				}
+				u := used[p.Dir]
+				u.All = u.All || usage.All
+				maps.Copy(u.Outputs, usage.Outputs)
+			}
+		}
+	}
+
+	for _, p := range producers {
+		u := used[p.Dir]
+		if u.All {
+			continue
+		}
+		outputs, ds := findOutputs(afs, p.Dir)
+		diags = diags.Extend(ds)
+		for _, output := range outputs {
+			if _, ok := u.Outputs[output.Name]; ok {
+				continue
+			}
+			if _, ok := usedByUnresolved[output.Name]; ok {
+				continue
+			}
+			relDir, relFile, err := relPaths(opts.WorkDir, opts.BaseDir, p.Dir, output.File)
+			if err != nil {
+				return nil, err
+			}
+			output.Dir = relDir
+			output.File = relFile
+			result.Outputs = append(result.Outputs, output)
+		}
+	}
+	result.Diagnostics = newDiagnostics(opts.WorkDir, opts.BaseDir, diags)
+	return result, nil
+}
+
+// refers returns true if a consumer refers a producer.
+func (d *consumerDecl) refers(p *producer) bool {
+	if d.Dir != "" {
+		return d.Dir == p.Dir
+	}
+	for _, bucket := range d.Buckets {
+		if bucket.Compare(p.Bucket) {
+			return true
+		}
+	}
+	return false
+}
+
+// findConsumerDecls returns terraform_remote_state and tfe_outputs data sources in *.tf and dependency blocks in terragrunt.hcl.
+// Consumers whose configuration can't be resolved statically are returned as unresolved consumers and their Buckets are nil.
+func findConsumerDecls(logger *slog.Logger, afs afero.Fs, registry *Registry, body *hclsyntax.Body, file *tfFile, moduleDir string, evalCtx *hcl.EvalContext) ([]*consumerDecl, []*Unresolved) {
+	var decls []*consumerDecl
+	var unresolved []*Unresolved
+	if isTerragruntFile(file.Path) {
+		tgCtx := newTerragruntEvalContext(logger, afs, body, moduleDir, moduleDir)
+		for _, block := range body.Blocks {
+			if block.Type != "dependency" || len(block.Labels) != 1 {
+				continue
+			}
+			decl := &consumerDecl{
+				Kind:  consumerKindDependency,
+				Name:  block.Labels[0],
+				File:  file.Path,
+				Range: block.DefRange(),
+			}
+			decls = append(decls, decl)
+			attr, ok := block.Body.Attributes["config_path"]
+			if !ok {
+				continue
+			}
+			configPath, err := evalString(attr.Expr, tgCtx)
+			if err != nil {
+				unresolved = append(unresolved, newUnresolved(block, file.Path, err))
+				continue
+			}
+			decl.Dir = absPath(moduleDir, configPath)
+		}
+		return decls, unresolved
+	}
+	for _, block := range body.Blocks {
+		instances, err := handleDataBlock(logger, registry, block, moduleDir, evalCtx)
+		if err == nil && instances == nil {
+			continue
+		}
+		decl := &consumerDecl{
+			Kind:  block.Labels[0],
+			Name:  block.Labels[1],
+			File:  file.Path,
+			Range: block.DefRange(),
+		}
+		decls = append(decls, decl)
+		if err != nil {
+			if _, ok := missingAttribute(err); !ok {
+				unresolved = append(unresolved, newUnresolved(block, file.Path, err))
+			}
+			continue
+		}
+		decl.Buckets = make([]*Bucket, len(instances))
+		for i, instance := range instances {
+			decl.Buckets[i] = instance.Bucket
+		}
+	}
+	return decls, unresolved
+}
+
+// findUsages walks all expressions in a file and records how consumers are used.
+// usages is a map of the kind and the name of consumers joined with ".".
+func findUsages(body *hclsyntax.Body, src []byte, usages map[string]*consumerUsage) {
+	kinds := consumerKinds()
+	// collections of references with dynamic instance keys such as data.terraform_remote_state.svc in data.terraform_remote_state.svc[each.key].outputs.vpc_id
+	dynamic := map[hclsyntax.Node]struct{}{}
+	hclsyntax.VisitAll(body, func(node hclsyntax.Node) hcl.Diagnostics {
+		for _, kind := range kinds {
+			switch expr := node.(type) {
+			case *hclsyntax.RelativeTraversalExpr:
+				ref := kind.parseDynamicReference(expr, src)
+				if ref == nil {
+					continue
+				}
+				if index, ok := expr.Source.(*hclsyntax.IndexExpr); ok {
+					dynamic[index.Collection] = struct{}{}
+				}
+				usageOf(usages, kind.Name, ref.DataSource).Outputs[ref.Output] = struct{}{}
+				return nil
+			case *hclsyntax.ScopeTraversalExpr:
+				if len(expr.Traversal) <= len(kind.Prefix) || !kind.matchPrefix(expr.Traversal) {
+					continue
+				}
+				name := traverseName(expr.Traversal[len(kind.Prefix)])
+				if name == "" {
+					continue
+				}
+				usage := usageOf(usages, kind.Name, name)
+				if ref := kind.parseReference(expr.Traversal); ref != nil {
+					usage.Outputs[ref.Output] = struct{}{}
+					return nil
+				}
+				if _, ok := dynamic[expr]; !ok {
+					usage.All = true
+				}
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+// usageOf returns the usage of a consumer. If it doesn't exist, it's created.
+func usageOf(usages map[string]*consumerUsage, kind, name string) *consumerUsage {
+	key := kind + "." + name
+	usage, ok := usages[key]
+	if !ok {
+		usage = &consumerUsage{Outputs: map[string]struct{}{}}
+		usages[key] = usage
+	}
+	return usage
+}
+
+// findOutputs returns output blocks in *.tf in dir.
+// Files which can't be parsed are returned as diagnostics.
+func findOutputs(afs afero.Fs, dir string) ([]*UnusedOutput, hcl.Diagnostics) {
+	files, err := afero.Glob(afs, filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, toDiagnostics(fmt.Errorf("glob *.tf: %w", slogerr.With(err, "dir", dir)), DiagnosticCodeParseError, dir)
+	}
+	var outputs []*UnusedOutput
+	var diags hcl.Diagnostics
+	for _, file := range files {
+		body, err := readHCLBody(afs, file)
+		if err != nil {
+			diags = diags.Extend(toDiagnostics(err, DiagnosticCodeParseError, file))
+			continue
+		}
+		for _, block := range body.Blocks {
+			if block.Type != "output" || len(block.Labels) != 1 {
+				continue
+			}
+			outputs = append(outputs, &UnusedOutput{
+				File:  file,
+				Name:  block.Labels[0],
+				Range: newRange(block.DefRange()),
+			})
+		}
+	}
+	slices.SortFunc(outputs, func(a, b *UnusedOutput) int {
+		return cmp.Or(strings.Compare(a.File, b.File), cmp.Compare(a.Range.Line, b.Range.Line))
+	})
+	return outputs, diags
+}
@@ -13,7 +13,7 @@ command_console() {
 }
 
 commands() {
-  for cmd in find completion version; do
+  for cmd in find lint unused rewrite schema completion version; do
     echo "
 ## tfrstate $cmd
 
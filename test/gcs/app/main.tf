@@ -0,0 +1,12 @@
+data "terraform_remote_state" "network" {
+  backend = "gcs"
+
+  config = {
+    bucket = "tf-state-prod"
+    prefix = "network"
+  }
+}
+
+locals {
+  vpc_id = data.terraform_remote_state.network.outputs.vpc_id
+}
@@ -0,0 +1,10 @@
+output "vpc_id" {
+  value = "vpc-xxx"
+}
+
+terraform {
+  backend "gcs" {
+    bucket = "tf-state-prod"
+    prefix = "network/"
+  }
+}
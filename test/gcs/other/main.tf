@@ -0,0 +1,12 @@
+data "terraform_remote_state" "network" {
+  backend = "gcs"
+
+  config = {
+    bucket = "gs://tf-state-prod"
+    prefix = "/network/default.tfstate"
+  }
+}
+
+locals {
+  vpc_id = data.terraform_remote_state.network.outputs.vpc_id
+}
@@ -0,0 +1,13 @@
+data "terraform_remote_state" "network" {
+  backend = "s3"
+
+  config = {
+    bucket = "terraform-state"
+    key    = "network/terraform.tfstate"
+    region = "ap-northeast-1"
+  }
+}
+
+locals {
+  vpc_id = data.terraform_remote_state.network.outputs.vpc_id
+}
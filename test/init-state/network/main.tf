@@ -0,0 +1,9 @@
+# The backend configuration is given by terraform init -backend-config.
+# It's recorded in .terraform/terraform.tfstate.
+terraform {
+  backend "s3" {}
+}
+
+output "vpc_id" {
+  value = "vpc-xxx"
+}
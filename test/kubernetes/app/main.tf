@@ -0,0 +1,12 @@
+data "terraform_remote_state" "network" {
+  backend = "kubernetes"
+
+  config = {
+    secret_suffix = "network"
+    namespace     = "default"
+  }
+}
+
+locals {
+  vpc_id = data.terraform_remote_state.network.outputs.vpc_id
+}
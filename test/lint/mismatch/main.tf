@@ -0,0 +1,18 @@
+data "terraform_remote_state" "network" {
+  backend = "s3"
+
+  config = {
+    bucket               = "terraform-state"
+    key                  = "network/terraform.tfstate"
+    region               = "us-east-1"
+    encrypt              = false
+    workspace_key_prefix = "env"
+    assume_role = {
+      role_arn = "arn:aws:iam::123456789012:role/readonly"
+    }
+  }
+}
+
+locals {
+  vpc_id = data.terraform_remote_state.network.outputs.vpc_id
+}
@@ -0,0 +1,13 @@
+# region, encrypt, workspace_key_prefix, and assume_role are missing
+data "terraform_remote_state" "network" {
+  backend = "s3"
+
+  config = {
+    bucket = "terraform-state"
+    key    = "network/terraform.tfstate"
+  }
+}
+
+locals {
+  vpc_id = data.terraform_remote_state.network.outputs.vpc_id
+}
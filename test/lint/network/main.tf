@@ -0,0 +1,16 @@
+terraform {
+  backend "s3" {
+    bucket               = "terraform-state"
+    key                  = "network/terraform.tfstate"
+    region               = "us-east-1"
+    encrypt              = true
+    workspace_key_prefix = "envs"
+    assume_role = {
+      role_arn = "arn:aws:iam::123456789012:role/terraform"
+    }
+  }
+}
+
+output "vpc_id" {
+  value = "vpc-xxx"
+}
@@ -0,0 +1,17 @@
+# The deprecated attribute role_arn is equivalent to assume_role.role_arn
+data "terraform_remote_state" "network" {
+  backend = "s3"
+
+  config = {
+    bucket               = "terraform-state"
+    key                  = "network/terraform.tfstate"
+    region               = "us-east-1"
+    encrypt              = "true"
+    workspace_key_prefix = "envs"
+    role_arn             = "arn:aws:iam::123456789012:role/terraform"
+  }
+}
+
+locals {
+  vpc_id = data.terraform_remote_state.network.outputs.vpc_id
+}
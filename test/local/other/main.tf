@@ -0,0 +1,12 @@
+# The path is spelled differently but it's the same state file as local/app
+data "terraform_remote_state" "network" {
+  backend = "local"
+
+  config = {
+    path = "./../other/../network/./terraform.tfstate"
+  }
+}
+
+locals {
+  vpc_id = data.terraform_remote_state.network.outputs.vpc_id
+}
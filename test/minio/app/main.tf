@@ -0,0 +1,17 @@
+data "terraform_remote_state" "network" {
+  backend = "s3"
+
+  config = {
+    bucket = "tfstate"
+    key    = "network/terraform.tfstate"
+    region = "us-east-1"
+    endpoints = {
+      s3 = "https://MINIO-A.example.com/"
+    }
+    use_path_style = true
+  }
+}
+
+locals {
+  vpc_id = data.terraform_remote_state.network.outputs.vpc_id
+}
@@ -0,0 +1,15 @@
+output "vpc_id" {
+  value = "vpc-xxx"
+}
+
+terraform {
+  backend "s3" {
+    bucket = "tfstate"
+    key    = "network/terraform.tfstate"
+    region = "us-east-1"
+    endpoints = {
+      s3 = "https://minio-a.example.com"
+    }
+    use_path_style = true
+  }
+}
@@ -0,0 +1,18 @@
+# The bucket has the same name but it's in another MinIO cluster
+data "terraform_remote_state" "network" {
+  backend = "s3"
+
+  config = {
+    bucket = "tfstate"
+    key    = "network/terraform.tfstate"
+    region = "us-east-1"
+    endpoints = {
+      s3 = "https://minio-b.example.com"
+    }
+    use_path_style = true
+  }
+}
+
+locals {
+  vpc_id = data.terraform_remote_state.network.outputs.vpc_id
+}
@@ -0,0 +1,8 @@
+# Terraform fails because backend.tf also has a backend block
+terraform {
+  backend "s3" {
+    bucket = "terraform-state-dev"
+    key    = "network/terraform.tfstate"
+    region = "us-east-1"
+  }
+}
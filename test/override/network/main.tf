@@ -0,0 +1,15 @@
+terraform {
+  required_version = ">= 1.0.0"
+}
+
+terraform {
+  backend "s3" {
+    bucket = "terraform-state-dev"
+    key    = "network/terraform.tfstate"
+    region = "us-east-1"
+  }
+}
+
+output "vpc_id" {
+  value = "vpc-xxx"
+}
@@ -0,0 +1,8 @@
+# The backend in override files replaces the backend in primary files entirely
+terraform {
+  backend "s3" {
+    bucket = "terraform-state-prod"
+    key    = "network/terraform.tfstate"
+    region = "us-east-1"
+  }
+}
@@ -0,0 +1,14 @@
+# This refers to the backend overridden by override.tf
+data "terraform_remote_state" "network" {
+  backend = "s3"
+
+  config = {
+    bucket = "terraform-state-dev"
+    key    = "network/terraform.tfstate"
+    region = "us-east-1"
+  }
+}
+
+locals {
+  vpc_id = data.terraform_remote_state.network.outputs.vpc_id
+}
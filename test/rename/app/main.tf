@@ -0,0 +1,13 @@
+data "terraform_remote_state" "network" {
+  backend = "local"
+
+  config = {
+    path = "../network/terraform.tfstate"
+  }
+}
+
+locals {
+  vpc_id     = data.terraform_remote_state.network.outputs.vpc_id
+  vpc_cidr   = data.terraform_remote_state.network.outputs["vpc_info"].cidr
+  subnet_ids = data.terraform_remote_state.network.outputs.subnet_ids
+}
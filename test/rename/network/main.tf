@@ -0,0 +1,17 @@
+# vpc_id is renamed to network_vpc_id and subnet_ids is removed
+output "network_vpc_id" {
+  value = "vpc-xxx"
+}
+
+output "vpc" {
+  value = {
+    id   = "vpc-xxx"
+    cidr = "10.0.0.0/16"
+  }
+}
+
+terraform {
+  backend "local" {
+    path = "terraform.tfstate"
+  }
+}
@@ -0,0 +1,33 @@
+data "terraform_remote_state" "network" {
+  backend = "local"
+
+  config = {
+    path = "../network/terraform.tfstate"
+  }
+}
+
+data "terraform_remote_state" "networks" {
+  for_each = toset(["network"])
+  backend  = "local"
+
+  config = {
+    path = "../${each.key}/terraform.tfstate"
+  }
+}
+
+# The other producer also has vpc_id, but it isn't rewritten
+data "terraform_remote_state" "other" {
+  backend = "local"
+
+  config = {
+    path = "../other/terraform.tfstate"
+  }
+}
+
+locals {
+  vpc_id        = data.terraform_remote_state.network.outputs.vpc_id # comment
+  vpc_id_index  = data.terraform_remote_state.network.outputs["vpc_id"]
+  vpc_id_each   = data.terraform_remote_state.networks["network"].outputs.vpc_id
+  other_vpc_id  = data.terraform_remote_state.other.outputs.vpc_id
+  vpc_id_string = "${data.terraform_remote_state.network.outputs.vpc_id}-suffix"
+}
@@ -0,0 +1,11 @@
+include "root" {
+  path = find_in_parent_folders("root.hcl")
+}
+
+dependency "app" {
+  config_path = "../app"
+}
+
+inputs = {
+  app_id = dependency.app.outputs.app_id
+}
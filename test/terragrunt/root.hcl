@@ -0,0 +1,12 @@
+locals {
+  bucket = "tg-state"
+}
+
+remote_state {
+  backend = "s3"
+  config = {
+    bucket = local.bucket
+    key    = "${path_relative_to_include()}/terraform.tfstate"
+    region = get_env("AWS_REGION", "us-east-1")
+  }
+}
@@ -0,0 +1,9 @@
+data "tfe_outputs" "network" {
+  organization = "my-org"
+  workspace    = "network"
+}
+
+locals {
+  vpc_id    = data.tfe_outputs.network.values.vpc_id
+  subnet_id = data.tfe_outputs.network.nonsensitive_values.subnet_id
+}
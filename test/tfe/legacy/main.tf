@@ -0,0 +1,14 @@
+data "terraform_remote_state" "network" {
+  backend = "remote"
+
+  config = {
+    organization = "my-org"
+    workspaces = {
+      name = "network"
+    }
+  }
+}
+
+locals {
+  vpc_id = data.terraform_remote_state.network.outputs.vpc_id
+}
@@ -0,0 +1,12 @@
+output "vpc_id" {
+  value = "vpc-xxx"
+}
+
+terraform {
+  cloud {
+    organization = "my-org"
+    workspaces {
+      name = "network"
+    }
+  }
+}
@@ -0,0 +1,26 @@
+data "terraform_remote_state" "network" {
+  backend = "local"
+
+  config = {
+    path = "../network/terraform.tfstate"
+  }
+}
+
+data "terraform_remote_state" "security" {
+  backend = "local"
+
+  config = {
+    path = "../security/terraform.tfstate"
+  }
+}
+
+variable "security_output" {
+  type    = string
+  default = "security_group_id"
+}
+
+locals {
+  vpc_id        = data.terraform_remote_state.network.outputs.vpc_id
+  subnet_ids    = data.terraform_remote_state.network.outputs["subnet_ids"]
+  security_item = data.terraform_remote_state.security.outputs[var.security_output]
+}
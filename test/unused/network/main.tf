@@ -0,0 +1,18 @@
+output "vpc_id" {
+  value = "vpc-xxx"
+}
+
+output "subnet_ids" {
+  value = ["subnet-xxx"]
+}
+
+# No consumer refers this output
+output "nat_gateway_id" {
+  value = "nat-xxx"
+}
+
+terraform {
+  backend "local" {
+    path = "terraform.tfstate"
+  }
+}
@@ -0,0 +1,14 @@
+# All outputs are treated as used because the consumer refers outputs with a dynamic key
+output "security_group_id" {
+  value = "sg-xxx"
+}
+
+output "role_arn" {
+  value = "arn:aws:iam::000000000000:role/xxx"
+}
+
+terraform {
+  backend "local" {
+    path = "terraform.tfstate"
+  }
+}
@@ -0,0 +1,8 @@
+# This data source is never referenced
+data "terraform_remote_state" "network" {
+  backend = "local"
+
+  config = {
+    path = "../network/terraform.tfstate"
+  }
+}
@@ -0,0 +1,80 @@
+// workdir checks that the library resolves relative paths from Options.WorkDir instead of the current directory.
+//
+//	go run ./test/workdir "$PWD/test"
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/spf13/afero"
+	"github.com/suzuki-shunsuke/tfrstate/pkg/tfrstate"
+)
+
+func main() {
+	if err := core(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func core() error {
+	if len(os.Args) != 2 { //nolint:mnd
+		return errors.New("usage: workdir <absolute path of the test directory>")
+	}
+	workDir := os.Args[1]
+	// Move to an empty directory unrelated to WorkDir
+	tempDir, err := os.MkdirTemp("", "tfrstate-workdir-")
+	if err != nil {
+		return fmt.Errorf("create a temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+	if err := os.Chdir(tempDir); err != nil {
+		return fmt.Errorf("change the current directory: %w", err)
+	}
+	afs := afero.NewOsFs()
+	logger := slog.New(slog.DiscardHandler)
+	outputs, err := tfrstate.ReadPlanOutputs(afs, workDir+"/foo/plan.json")
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	result, err := tfrstate.Find(context.Background(), logger, afs, &tfrstate.Options{
+		WorkDir:        workDir,
+		BaseDir:        ".",
+		BackendDir:     "foo",
+		ChangedOutputs: outputs.Changed,
+	})
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	if len(result.Changes) != 2 { //nolint:mnd
+		return fmt.Errorf("the number of changes must be 2 but got %d", len(result.Changes))
+	}
+
+	lintResult, err := tfrstate.Lint(context.Background(), logger, afs, &tfrstate.LintOptions{
+		WorkDir: workDir,
+		BaseDir: "lint",
+	})
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	if !tfrstate.HasErrors(lintResult.Diagnostics) {
+		return errors.New("lint must report errors")
+	}
+
+	unusedResult, err := tfrstate.FindUnused(context.Background(), logger, afs, &tfrstate.UnusedOptions{
+		WorkDir: workDir,
+		BaseDir: "unused",
+	})
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	if len(unusedResult.DataSources) != 1 || len(unusedResult.Outputs) != 1 {
+		return fmt.Errorf("unused must report a data source and an output but got %d data sources and %d outputs", len(unusedResult.DataSources), len(unusedResult.Outputs))
+	}
+	fmt.Fprintln(os.Stdout, "ok")
+	return nil
+}
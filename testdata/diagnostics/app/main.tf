@@ -0,0 +1,16 @@
+data "terraform_remote_state" "network" {
+  backend = "local"
+
+  config = {
+    path = "../network/terraform.tfstate"
+  }
+}
+
+# config is missing
+data "terraform_remote_state" "legacy" {
+  backend = "local"
+}
+
+locals {
+  vpc_id = data.terraform_remote_state.network.outputs.vpc_id
+}
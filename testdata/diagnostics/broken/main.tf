@@ -0,0 +1,10 @@
+data "terraform_remote_state" "network" {
+  backend = "local"
+
+  config = {
+    path = "../network/terraform.tfstate"
+  }
+
+locals {
+  vpc_id = data.terraform_remote_state.network.outputs.vpc_id
+}
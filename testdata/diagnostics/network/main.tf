@@ -0,0 +1,9 @@
+output "vpc_id" {
+  value = "vpc-xxx"
+}
+
+terraform {
+  backend "local" {
+    path = "terraform.tfstate"
+  }
+}
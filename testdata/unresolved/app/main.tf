@@ -0,0 +1,16 @@
+# env has no value, so the config can't be resolved statically
+variable "env" {
+  type = string
+}
+
+data "terraform_remote_state" "network" {
+  backend = "local"
+
+  config = {
+    path = "../${var.env}/terraform.tfstate"
+  }
+}
+
+locals {
+  vpc_id = data.terraform_remote_state.network.outputs.vpc_id
+}